@@ -0,0 +1,168 @@
+// Package audit provides a tamper evident, append only log of changes to CAA
+// values. Each Entry includes the SHA-256 hash of the Entry before it so that
+// Verify can detect entries that have been edited, removed or reordered.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/endiangroup/compandauth"
+	"github.com/endiangroup/compandauth/clock"
+)
+
+var (
+	// ErrTampered is returned by Verify when the hash chain is broken.
+	ErrTampered = errors.New("audit: log has been tampered with")
+	// ErrUnknownState is returned by RecordState when the state of the CAA
+	// is unknown, rather than recording meaningless values.
+	ErrUnknownState = errors.New("audit: caa state is unknown")
+)
+
+type Action string
+
+const (
+	Issue  Action = "issue"
+	Revoke Action = "revoke"
+	Lock   Action = "lock"
+	Unlock Action = "unlock"
+)
+
+// Entry records a single change to the CAA of an entity. Seq starts at 1 and
+// increments by 1 for every Entry in a Log. PrevHash is empty for the first
+// Entry. PreviousAux and NewAux are the second of the pair for CAAs stored as
+// a pair of int64s, see compandauth.State.
+type Entry struct {
+	Seq      uint64    `json:"seq"`
+	Entity   string    `json:"entity"`
+	Action   Action    `json:"action"`
+	Actor    string    `json:"actor"`
+	Reason   string    `json:"reason"`
+	Time     time.Time `json:"time"`
+	Previous int64     `json:"previous"`
+	New      int64     `json:"new"`
+	PrevHash string    `json:"prev_hash"`
+	Hash     string    `json:"hash"`
+
+	PreviousAux int64 `json:"previous_aux,omitempty"`
+	NewAux      int64 `json:"new_aux,omitempty"`
+}
+
+// Computes the hash of every field of the Entry bar Hash itself.
+func (e Entry) computeHash() string {
+	e.Hash = ""
+	// Marshalling a struct of plain fields can't fail
+	b, _ := json.Marshal(e)
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// Writer persists entries, in order, as they are recorded.
+type Writer interface {
+	Write(Entry) error
+}
+
+// Log chains and writes entries. It is safe for concurrent use.
+type Log struct {
+	mu       sync.Mutex
+	w        Writer
+	seq      uint64
+	lastHash string
+}
+
+// Returns a Log starting a new chain.
+func NewLog(w Writer) *Log {
+	return &Log{w: w}
+}
+
+// Returns a Log continuing the chain from last, e.g. after reading back a
+// previously written log.
+func ResumeLog(w Writer, last Entry) *Log {
+	return &Log{w: w, seq: last.Seq, lastHash: last.Hash}
+}
+
+// Records a change to the CAA of entity from previous to new, timestamped with
+// clock.Now. If the Entry can't be written the chain is left unchanged.
+func (l *Log) Record(entity string, action Action, actor, reason string, previous, new int64) (Entry, error) {
+	return l.record(Entry{
+		Entity:   entity,
+		Action:   action,
+		Actor:    actor,
+		Reason:   reason,
+		Previous: previous,
+		New:      new,
+	})
+}
+
+// Records a change to the CAA of entity as Record does, including the second
+// of the pair for CAAs stored as a pair of int64s. Returns ErrUnknownState
+// without recording anything if either state is unknown.
+func (l *Log) RecordState(entity string, action Action, actor, reason string, previous, new compandauth.State) (Entry, error) {
+	if !previous.Known || !new.Known {
+		return Entry{}, ErrUnknownState
+	}
+
+	return l.record(Entry{
+		Entity:      entity,
+		Action:      action,
+		Actor:       actor,
+		Reason:      reason,
+		Previous:    previous.Value,
+		New:         new.Value,
+		PreviousAux: previous.Aux,
+		NewAux:      new.Aux,
+	})
+}
+
+func (l *Log) record(e Entry) (Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e.Seq = l.seq + 1
+	e.Time = clock.Now()
+	e.PrevHash = l.lastHash
+	e.Hash = e.computeHash()
+
+	if err := l.w.Write(e); err != nil {
+		return Entry{}, err
+	}
+
+	l.seq = e.Seq
+	l.lastHash = e.Hash
+
+	return e, nil
+}
+
+// Verify checks entries form an unbroken chain, returning an error wrapping
+// ErrTampered describing the first break found.
+func Verify(entries []Entry) error {
+	prev := Entry{}
+
+	for _, e := range entries {
+		if err := verifyNext(prev, e); err != nil {
+			return err
+		}
+		prev = e
+	}
+
+	return nil
+}
+
+func verifyNext(prev, e Entry) error {
+	switch {
+	case e.Seq != prev.Seq+1:
+		return fmt.Errorf("%w: expected entry %d, got %d", ErrTampered, prev.Seq+1, e.Seq)
+	case e.PrevHash != prev.Hash:
+		return fmt.Errorf("%w: entry %d does not follow entry %d", ErrTampered, e.Seq, prev.Seq)
+	case e.Hash != e.computeHash():
+		return fmt.Errorf("%w: entry %d has been modified", ErrTampered, e.Seq)
+	}
+
+	return nil
+}
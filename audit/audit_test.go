@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/endiangroup/compandauth"
+	"github.com/endiangroup/compandauth/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryWriter struct {
+	entries []Entry
+	err     error
+}
+
+func (m *memoryWriter) Write(e Entry) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+func recordEntries(t *testing.T, n int) []Entry {
+	w := &memoryWriter{}
+	log := NewLog(w)
+
+	for i := 0; i < n; i++ {
+		_, err := log.Record("user-1", Revoke, "admin", "password-change", int64(i), int64(i+1))
+		assert.NoError(t, err)
+	}
+
+	return w.entries
+}
+
+func Test_Record_ChainsEntries(t *testing.T) {
+	now := time.Now().UTC()
+	clock.NowForce(now)
+	defer clock.NowReset()
+
+	entries := recordEntries(t, 3)
+
+	assert.Equal(t, uint64(1), entries[0].Seq)
+	assert.Equal(t, "", entries[0].PrevHash)
+	assert.Equal(t, now, entries[0].Time)
+	assert.Equal(t, "admin", entries[0].Actor)
+	assert.Equal(t, int64(0), entries[0].Previous)
+	assert.Equal(t, int64(1), entries[0].New)
+	assert.Len(t, entries[0].Hash, 64)
+
+	for i := 1; i < len(entries); i++ {
+		assert.Equal(t, uint64(i+1), entries[i].Seq)
+		assert.Equal(t, entries[i-1].Hash, entries[i].PrevHash)
+	}
+
+	assert.NoError(t, Verify(entries))
+}
+
+func Test_Record_LeavesChainUnchangedWhenWriteFails(t *testing.T) {
+	w := &memoryWriter{}
+	log := NewLog(w)
+
+	_, err := log.Record("user-1", Lock, "admin", "", 1, -1)
+	assert.NoError(t, err)
+
+	w.err = errors.New("disk full")
+	_, err = log.Record("user-1", Unlock, "admin", "", -1, 1)
+	assert.Equal(t, w.err, err)
+
+	w.err = nil
+	_, err = log.Record("user-1", Unlock, "admin", "", -1, 1)
+	assert.NoError(t, err)
+
+	assert.NoError(t, Verify(w.entries))
+}
+
+func Test_ResumeLog_ContinuesChain(t *testing.T) {
+	entries := recordEntries(t, 2)
+	w := &memoryWriter{entries: entries}
+
+	_, err := ResumeLog(w, entries[1]).Record("user-1", Issue, "user-1", "login", 3, 4)
+	assert.NoError(t, err)
+
+	assert.NoError(t, Verify(w.entries))
+}
+
+func Test_Verify_DetectsTampering(t *testing.T) {
+	tests := map[string]func([]Entry) []Entry{
+		"edited": func(entries []Entry) []Entry {
+			entries[1].Actor = "someone-else"
+			return entries
+		},
+		"edited and rehashed": func(entries []Entry) []Entry {
+			entries[1].New = 100
+			entries[1].Hash = entries[1].computeHash()
+			return entries
+		},
+		"removed": func(entries []Entry) []Entry {
+			return append(entries[:1], entries[2:]...)
+		},
+		"reordered": func(entries []Entry) []Entry {
+			entries[1], entries[2] = entries[2], entries[1]
+			return entries
+		},
+		"truncated start": func(entries []Entry) []Entry {
+			return entries[1:]
+		},
+	}
+
+	for name, tamper := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Verify(tamper(recordEntries(t, 4)))
+
+			assert.True(t, errors.Is(err, ErrTampered), "%v", err)
+		})
+	}
+}
+
+func Test_Observer_RecordsObservedTransitions(t *testing.T) {
+	w := &memoryWriter{}
+	log := NewLog(w)
+	observer := log.Observer("user-1", "admin", "suspected-compromise")
+	caa := compandauth.NewObserved(compandauth.NewCounter(), observer)
+
+	caa.Issue()
+	caa.IsValid(5, 1)
+	caa.Revoke(1)
+	caa.Lock()
+	caa.Unlock()
+
+	assert.NoError(t, observer.Err())
+	assert.NoError(t, Verify(w.entries))
+
+	actions := []Action{}
+	for _, e := range w.entries {
+		assert.Equal(t, "user-1", e.Entity)
+		assert.Equal(t, "suspected-compromise", e.Reason)
+		actions = append(actions, e.Action)
+	}
+	assert.Equal(t, []Action{Issue, Revoke, Lock, Unlock}, actions)
+	assert.Equal(t, int64(2), w.entries[2].Previous)
+	assert.Equal(t, int64(-2), w.entries[2].New)
+}
+
+func Test_Observer_RecordsBothValuesOfCAAsStoredAsAPair(t *testing.T) {
+	w := &memoryWriter{}
+	caa := compandauth.NewObserved(compandauth.NewRefreshFamily(compandauth.ReuseRevoke), NewLog(w).Observer("user-1", "", ""))
+
+	caa.Issue()
+	caa.Issue()
+
+	assert.NoError(t, Verify(w.entries))
+	assert.Equal(t, int64(1), w.entries[1].Previous)
+	assert.Equal(t, int64(0), w.entries[1].PreviousAux)
+	assert.Equal(t, int64(2), w.entries[1].New)
+	assert.Equal(t, int64(1), w.entries[1].NewAux)
+}
+
+type opaqueCAA struct {
+	compandauth.CAA
+}
+
+func Test_Observer_DoesNotRecordUnknownState(t *testing.T) {
+	w := &memoryWriter{}
+	observer := NewLog(w).Observer("user-1", "admin", "")
+	caa := compandauth.NewObserved(opaqueCAA{compandauth.NewCounter()}, observer)
+
+	caa.Issue()
+
+	assert.Equal(t, ErrUnknownState, observer.Err())
+	assert.Empty(t, w.entries)
+}
+
+func Test_Observer_KeepsFirstError(t *testing.T) {
+	w := &memoryWriter{err: errors.New("disk full")}
+	observer := NewLog(w).Observer("user-1", "admin", "")
+	caa := compandauth.NewObserved(compandauth.NewCounter(), observer)
+
+	caa.Issue()
+
+	assert.Equal(t, w.err, observer.Err())
+}
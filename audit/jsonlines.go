@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// JSONLines writes each Entry as a single line of JSON.
+type JSONLines struct {
+	enc *json.Encoder
+}
+
+func NewJSONLines(w io.Writer) *JSONLines {
+	return &JSONLines{enc: json.NewEncoder(w)}
+}
+
+func (j *JSONLines) Write(e Entry) error {
+	return j.enc.Encode(e)
+}
+
+// ReadJSONLines reads back entries written by JSONLines.
+func ReadJSONLines(r io.Reader) ([]Entry, error) {
+	entries := []Entry{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024)
+
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, scanner.Err()
+}
+
+// File is a JSONLines Writer appending to a file, syncing after every Entry.
+type File struct {
+	f *os.File
+	*JSONLines
+}
+
+// OpenFile opens, creating if necessary, the JSON lines log at path and
+// verifies its existing entries. The returned Log continues the chain by
+// appending to the file. Close the File when finished with the Log.
+func OpenFile(path string) (*Log, *File, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := ReadJSONLines(f)
+	if err == nil {
+		err = Verify(entries)
+	}
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	file := &File{f: f, JSONLines: NewJSONLines(f)}
+	if len(entries) == 0 {
+		return NewLog(file), file, nil
+	}
+
+	return ResumeLog(file, entries[len(entries)-1]), file, nil
+}
+
+func (f *File) Write(e Entry) error {
+	if err := f.JSONLines.Write(e); err != nil {
+		return err
+	}
+
+	return f.f.Sync()
+}
+
+func (f *File) Close() error {
+	return f.f.Close()
+}
+
+// VerifyFile reads and verifies the JSON lines log at path.
+func VerifyFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	entries, err := ReadJSONLines(f)
+	if err != nil {
+		return err
+	}
+
+	return Verify(entries)
+}
@@ -0,0 +1,78 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_JSONLines_RoundTripsEntries(t *testing.T) {
+	entries := recordEntries(t, 3)
+	buf := &bytes.Buffer{}
+	w := NewJSONLines(buf)
+
+	for _, e := range entries {
+		assert.NoError(t, w.Write(e))
+	}
+
+	read, err := ReadJSONLines(buf)
+	assert.NoError(t, err)
+	assert.NoError(t, Verify(read))
+	assert.Equal(t, len(entries), len(read))
+	for i := range entries {
+		assert.True(t, entries[i].Time.Equal(read[i].Time))
+		assert.Equal(t, entries[i].Hash, read[i].Hash)
+	}
+}
+
+func Test_OpenFile_AppendsToExistingChain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.jsonl")
+
+	for i := 0; i < 3; i++ {
+		log, f, err := OpenFile(path)
+		assert.NoError(t, err)
+
+		_, err = log.Record("user-1", Issue, "user-1", "login", int64(i), int64(i+1))
+		assert.NoError(t, err)
+		assert.NoError(t, f.Close())
+	}
+
+	assert.NoError(t, VerifyFile(path))
+
+	f, err := os.Open(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	entries, err := ReadJSONLines(f)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+}
+
+func Test_OpenFile_RefusesTamperedLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.jsonl")
+
+	log, f, err := OpenFile(path)
+	assert.NoError(t, err)
+	log.Record("user-1", Issue, "user-1", "login", 0, 1)
+	log.Record("user-1", Lock, "admin", "suspected-compromise", 1, -1)
+	f.Close()
+
+	contents, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	tampered := bytes.Replace(contents, []byte(`"admin"`), []byte(`"user-1"`), 1)
+	assert.NoError(t, ioutil.WriteFile(path, tampered, 0600))
+
+	_, _, err = OpenFile(path)
+	assert.True(t, errors.Is(err, ErrTampered))
+	assert.True(t, errors.Is(VerifyFile(path), ErrTampered))
+}
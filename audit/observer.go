@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"sync"
+
+	"github.com/endiangroup/compandauth"
+)
+
+// Observer records the transitions of a compandauth.Observed CAA to a Log on
+// behalf of an actor, e.g.
+//
+//	caa := compandauth.NewObserved(user.CAA, log.Observer(user.ID, "admin", "password-change"))
+//
+// Failed validations are not recorded as they don't change the CAA. Nor are
+// transitions of CAAs whose state is unknown, Err returns ErrUnknownState.
+type Observer struct {
+	log    *Log
+	entity string
+	actor  string
+	reason string
+
+	mu  sync.Mutex
+	err error
+}
+
+func (l *Log) Observer(entity, actor, reason string) *Observer {
+	return &Observer{log: l, entity: entity, actor: actor, reason: reason}
+}
+
+func (o *Observer) Observe(e compandauth.Event) {
+	var action Action
+	var t compandauth.Transition
+
+	switch e := e.(type) {
+	case compandauth.Issued:
+		action, t = Issue, e.Transition
+	case compandauth.Revoked:
+		action, t = Revoke, e.Transition
+	case compandauth.Locked:
+		action, t = Lock, e.Transition
+	case compandauth.Unlocked:
+		action, t = Unlock, e.Transition
+	default:
+		return
+	}
+
+	if _, err := o.log.RecordState(o.entity, action, o.actor, o.reason, t.Before, t.After); err != nil {
+		o.mu.Lock()
+		if o.err == nil {
+			o.err = err
+		}
+		o.mu.Unlock()
+	}
+}
+
+// Err returns the first error encountered recording an Entry, as Observe
+// has no way of returning it.
+func (o *Observer) Err() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.err
+}
+
+var _ = compandauth.Observer(&Observer{})
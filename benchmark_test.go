@@ -1,6 +1,7 @@
 package compandauth_test
 
 import (
+	"fmt"
 	"math/rand"
 	"testing"
 	"time"
@@ -101,3 +102,109 @@ func Benchmark_Timeout_Issue(b *testing.B) {
 
 	sessionCAAResult = sessionCAA
 }
+
+func Benchmark_AtomicCounter_Issue(b *testing.B) {
+	b.StopTimer()
+	entity := CounterEntity{Delta: 10, CAA: compandauth.NewAtomicCounter()}
+
+	var sessionCAA compandauth.SessionCAA
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		sessionCAA = entity.CAA.Issue()
+	}
+
+	sessionCAAResult = sessionCAA
+}
+
+func Benchmark_AtomicCounter_IssueParallel(b *testing.B) {
+	entity := CounterEntity{Delta: 10, CAA: compandauth.NewAtomicCounter()}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			entity.CAA.Issue()
+		}
+	})
+}
+
+func Benchmark_AtomicCounter_IsValidParallel(b *testing.B) {
+	entity := CounterEntity{Delta: 10, CAA: compandauth.NewAtomicCounter()}
+	for i := 0; i < 100; i++ {
+		entity.CAA.Issue()
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			entity.CAA.IsValid(95, entity.Delta)
+		}
+	})
+}
+
+func Benchmark_AtomicTimeout_Issue(b *testing.B) {
+	b.StopTimer()
+	entity := TimeoutEntity{Timeout: 30 * time.Second, CAA: compandauth.NewAtomicTimeout()}
+
+	var sessionCAA compandauth.SessionCAA
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		sessionCAA = entity.CAA.Issue()
+	}
+
+	sessionCAAResult = sessionCAA
+}
+
+func Benchmark_Registry_IssueParallel(b *testing.B) {
+	registry := compandauth.NewCounterRegistry(32)
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user-%d", i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := rand.Int()
+		for pb.Next() {
+			registry.Issue(keys[i%len(keys)])
+			i++
+		}
+	})
+}
+
+func Benchmark_Registry_ValidateParallel(b *testing.B) {
+	registry := compandauth.NewCounterRegistry(32)
+	keys := make([]string, 1024)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("user-%d", i)
+		registry.Issue(keys[i])
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := rand.Int()
+		for pb.Next() {
+			registry.Validate(keys[i%len(keys)], 0, 10)
+			i++
+		}
+	})
+}
+
+func Benchmark_CounterRecord_IsValid(b *testing.B) {
+	b.StopTimer()
+	caa := compandauth.NewCounterRecord()
+	numberOfSessions := 100000
+
+	sessions := make([]compandauth.SessionCAA, numberOfSessions)
+	for i := range sessions {
+		sessions[i] = compandauth.SessionCAA(rand.Intn(numberOfSessions))
+		if rand.Intn(2) == 0 {
+			caa.Issue()
+		}
+	}
+
+	var isValid bool
+	b.StartTimer()
+	for i := 0; i < b.N; i++ {
+		isValid = caa.IsValid(sessions[i%numberOfSessions], 10)
+	}
+
+	isValidResult = isValid
+}
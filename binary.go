@@ -0,0 +1,142 @@
+package compandauth
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Binary encoding of a CAA, tagged with its type so a Counter can't be
+// mistaken for a Timeout (or vice versa). Only Counter, Timeout and
+// TimeoutMillis are encoded, types holding more state (e.g. Sliding) hold
+// their Timeout in a named field so can't be encoded as a Timeout:
+//
+//	byte 0     version, BinaryVersion
+//	byte 1     type tag, see BinaryTag
+//	byte 2     flags, bit 0 set if locked
+//	bytes 3-10 absolute value, big endian
+const (
+	BinaryVersion = 1
+	binaryLen     = 11
+
+	binaryFlagLocked = 1 << 0
+)
+
+// BinaryTag identifies the type of a binary encoded CAA.
+type BinaryTag byte
+
+const (
+	BinaryTagCounter       BinaryTag = 1
+	BinaryTagTimeout       BinaryTag = 2
+	BinaryTagTimeoutMillis BinaryTag = 3
+)
+
+// Decodes a binary encoded CAA into its concrete type, a *Counter, *Timeout
+// or *TimeoutMillis. Returns ErrMalformedCAA for any other input.
+func Decode(b []byte) (CAA, error) {
+	tag, v, err := decodeBinary(b)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tag {
+	case BinaryTagCounter:
+		caa := Counter(v)
+		return &caa, nil
+	case BinaryTagTimeout:
+		caa := Timeout(v)
+		return &caa, nil
+	case BinaryTagTimeoutMillis:
+		caa := TimeoutMillis(v)
+		return &caa, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown type tag %d", ErrMalformedCAA, tag)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (caa Counter) MarshalBinary() ([]byte, error) {
+	return encodeBinary(BinaryTagCounter, int64(caa)), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, returning
+// ErrMalformedCAA if b isn't a binary encoded Counter.
+func (caa *Counter) UnmarshalBinary(b []byte) error {
+	return unmarshalBinary(BinaryTagCounter, (*int64)(caa), b)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (caa Timeout) MarshalBinary() ([]byte, error) {
+	return encodeBinary(BinaryTagTimeout, int64(caa)), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, returning
+// ErrMalformedCAA if b isn't a binary encoded Timeout.
+func (caa *Timeout) UnmarshalBinary(b []byte) error {
+	return unmarshalBinary(BinaryTagTimeout, (*int64)(caa), b)
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+func (caa TimeoutMillis) MarshalBinary() ([]byte, error) {
+	return encodeBinary(BinaryTagTimeoutMillis, int64(caa)), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler, returning
+// ErrMalformedCAA if b isn't a binary encoded TimeoutMillis.
+func (caa *TimeoutMillis) UnmarshalBinary(b []byte) error {
+	return unmarshalBinary(BinaryTagTimeoutMillis, (*int64)(caa), b)
+}
+
+func encodeBinary(tag BinaryTag, v int64) []byte {
+	b := make([]byte, binaryLen)
+	b[0] = BinaryVersion
+	b[1] = byte(tag)
+	if v < 0 {
+		b[2] |= binaryFlagLocked
+	}
+	binary.BigEndian.PutUint64(b[3:], uint64(abs(v)))
+
+	return b
+}
+
+func unmarshalBinary(tag BinaryTag, v *int64, b []byte) error {
+	got, i, err := decodeBinary(b)
+	if err != nil {
+		return err
+	}
+	if got != tag {
+		return fmt.Errorf("%w: type tag %d, expected %d", ErrMalformedCAA, got, tag)
+	}
+
+	*v = i
+	return nil
+}
+
+// Decodes the header and value, rejecting unknown versions and flags, values
+// that don't fit in an int64 and a locked CAA that has never issued.
+func decodeBinary(b []byte) (BinaryTag, int64, error) {
+	switch {
+	case len(b) != binaryLen:
+		return 0, 0, fmt.Errorf("%w: length %d, expected %d", ErrMalformedCAA, len(b), binaryLen)
+	case b[0] != BinaryVersion:
+		return 0, 0, fmt.Errorf("%w: unsupported version %d", ErrMalformedCAA, b[0])
+	case b[2]&^binaryFlagLocked != 0:
+		return 0, 0, fmt.Errorf("%w: unknown flags %#x", ErrMalformedCAA, b[2])
+	}
+
+	u := binary.BigEndian.Uint64(b[3:])
+	locked := b[2]&binaryFlagLocked != 0
+	switch {
+	case u > math.MaxInt64:
+		return 0, 0, fmt.Errorf("%w: value %d out of range", ErrMalformedCAA, u)
+	case locked && u == 0:
+		return 0, 0, fmt.Errorf("%w: locked but never issued", ErrMalformedCAA)
+	}
+
+	v := int64(u)
+	if locked {
+		v = -v
+	}
+
+	return BinaryTag(b[1]), v, nil
+}
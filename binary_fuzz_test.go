@@ -0,0 +1,41 @@
+//go:build go1.18
+// +build go1.18
+
+package compandauth
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"testing"
+)
+
+func Fuzz_Decode(f *testing.F) {
+	for _, caa := range []encoding.BinaryMarshaler{
+		setCounterCAA(0), setCounterCAA(-12), setTimeoutCAA(1500000000),
+	} {
+		b, _ := caa.MarshalBinary()
+		f.Add(b)
+	}
+	f.Add([]byte{1, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0})
+	f.Add([]byte{1, 1, 0, 0x80, 0, 0, 0, 0, 0, 0, 0})
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		caa, err := Decode(b)
+		if err != nil {
+			if !errors.Is(err, ErrMalformedCAA) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			return
+		}
+
+		// Anything that decodes must re-encode to the same bytes
+		encoded, err := caa.(encoding.BinaryMarshaler).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(b, encoded) {
+			t.Fatalf("decoded %x re-encoded as %x", b, encoded)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package compandauth
+
+import (
+	"encoding"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MarshalBinary_EncodesHeaderAndAbsoluteValue(t *testing.T) {
+	b, err := setCounterCAA(-258).MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 1, 1, 0, 0, 0, 0, 0, 0, 1, 2}, b)
+
+	b, err = setTimeoutCAA(1500000000).MarshalBinary()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 0, 0, 0, 0, 0, 0x59, 0x68, 0x2f, 0}, b)
+}
+
+func Test_Decode_ReconstructsConcreteType(t *testing.T) {
+	millis := TimeoutMillis(-1500000000000)
+	tests := []encoding.BinaryMarshaler{
+		setCounterCAA(0),
+		setCounterCAA(12),
+		setCounterCAA(-12),
+		setCounterCAA(math.MaxInt64),
+		setTimeoutCAA(-math.MaxInt64),
+		setTimeoutCAA(1500000000),
+		&millis,
+	}
+
+	for _, test := range tests {
+		b, err := test.MarshalBinary()
+		assert.NoError(t, err)
+
+		caa, err := Decode(b)
+		assert.NoError(t, err)
+		assert.Equal(t, test, caa)
+	}
+}
+
+func Test_UnmarshalBinary_RejectsOtherTypes(t *testing.T) {
+	b, _ := setCounterCAA(1600000000).MarshalBinary()
+
+	caa := setTimeoutCAA(5)
+	err := caa.UnmarshalBinary(b)
+
+	assert.True(t, errors.Is(err, ErrMalformedCAA), "%v", err)
+	assert.Equal(t, setTimeoutCAA(5), caa)
+}
+
+func Test_Decode_RejectsMalformedInput(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":         nil,
+		"short":         {1, 1, 0, 0, 0, 0, 0, 0, 0, 1},
+		"long":          {1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0},
+		"version":       {2, 1, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+		"unknown type":  {1, 9, 0, 0, 0, 0, 0, 0, 0, 0, 1},
+		"unknown flags": {1, 1, 2, 0, 0, 0, 0, 0, 0, 0, 1},
+		"overflow":      {1, 1, 0, 0x80, 0, 0, 0, 0, 0, 0, 0},
+		"locked zero":   {1, 1, 1, 0, 0, 0, 0, 0, 0, 0, 0},
+	}
+
+	for name, b := range tests {
+		t.Run(name, func(t *testing.T) {
+			caa, err := Decode(b)
+			assert.Nil(t, caa)
+			assert.True(t, errors.Is(err, ErrMalformedCAA), "%v", err)
+		})
+	}
+}
+
+func Test_MarshalBinary_IsNotPromotedToTypesWrappingTimeout(t *testing.T) {
+	for _, caa := range []CAA{NewSliding(0), NewTimeoutWithClock(nil)} {
+		_, ok := caa.(encoding.BinaryMarshaler)
+		assert.False(t, ok, "%T", caa)
+		_, ok = caa.(encoding.BinaryUnmarshaler)
+		assert.False(t, ok, "%T", caa)
+	}
+}
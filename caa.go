@@ -1,5 +1,22 @@
 package compandauth
 
+import "errors"
+
+var (
+	// ErrLocked is returned when validating against a locked CAA.
+	ErrLocked = errors.New("compandauth: caa is locked")
+	// ErrNeverIssued is returned when validating against a CAA that has
+	// never issued a session.
+	ErrNeverIssued = errors.New("compandauth: caa has never issued")
+	// ErrRevoked is returned when a session CAA has been revoked.
+	ErrRevoked = errors.New("compandauth: session has been revoked")
+	// ErrExpired is returned when a session CAA has outlived its duration.
+	ErrExpired = errors.New("compandauth: session has expired")
+	// ErrFutureSession is returned when a session CAA is ahead of anything
+	// the CAA could have issued.
+	ErrFutureSession = errors.New("compandauth: session is from the future")
+)
+
 type SessionCAA int64
 
 type CAA interface {
@@ -8,8 +25,39 @@ type CAA interface {
 	IsLocked() bool
 
 	IsValid(SessionCAA, int64) bool
+	Validate(SessionCAA, int64) error
 
 	Revoke(int64)
 	Issue() SessionCAA
 	HasIssued() bool
 }
+
+// Kind names one of the CAAs stored as a single int64, e.g. for the type of a
+// Scoped scope, a Record or the structured encoding.
+type Kind string
+
+const (
+	KindCounter       Kind = "counter"
+	KindTimeout       Kind = "timeout"
+	KindTimeoutMillis Kind = "timeout-millis"
+)
+
+// Returns the CAA of kind k viewing v, nil if k is unknown.
+func newKindCAA(k Kind, v *int64) CAA {
+	switch k {
+	case KindCounter:
+		return (*Counter)(v)
+	case KindTimeout:
+		return (*Timeout)(v)
+	case KindTimeoutMillis:
+		return (*TimeoutMillis)(v)
+	}
+
+	return nil
+}
+
+// Rotator is implemented by CAAs that can validate a session CAA and issue its
+// replacement as a single operation.
+type Rotator interface {
+	Rotate(SessionCAA, int64) (SessionCAA, error)
+}
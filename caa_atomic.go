@@ -0,0 +1,181 @@
+package compandauth
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/endiangroup/compandauth/clock"
+)
+
+// AtomicCounter is a Counter that can be safely shared between goroutines
+// without a mutex. All mutations are performed with compare-and-swap loops on
+// the underlying int64 so they are linearizable.
+type AtomicCounter int64
+
+func NewAtomicCounter() *AtomicCounter {
+	return new(AtomicCounter)
+}
+
+// Returns a snapshot of the current Counter value, e.g. for persisting.
+func (caa *AtomicCounter) Load() Counter {
+	return Counter(atomic.LoadInt64((*int64)(caa)))
+}
+
+// Replaces the current value with c, e.g. after loading from storage.
+func (caa *AtomicCounter) Store(c Counter) {
+	atomic.StoreInt64((*int64)(caa), int64(c))
+}
+
+func (caa *AtomicCounter) Lock() {
+	caa.update((*Counter).Lock)
+}
+
+func (caa *AtomicCounter) Unlock() {
+	caa.update((*Counter).Unlock)
+}
+
+func (caa *AtomicCounter) IsLocked() bool {
+	return caa.Load().IsLocked()
+}
+
+func (caa *AtomicCounter) IsValid(s SessionCAA, delta int64) bool {
+	return caa.Load().IsValid(s, delta)
+}
+
+func (caa *AtomicCounter) Validate(s SessionCAA, delta int64) error {
+	return caa.Load().Validate(s, delta)
+}
+
+func (caa *AtomicCounter) Revoke(n int64) {
+	caa.update(func(c *Counter) { c.Revoke(n) })
+}
+
+func (caa *AtomicCounter) Issue() SessionCAA {
+	for {
+		old := caa.Load()
+		next := old
+		sessionCAA := next.Issue()
+
+		if caa.compareAndSwap(old, next) {
+			return sessionCAA
+		}
+	}
+}
+
+func (caa *AtomicCounter) HasIssued() bool {
+	return caa.Load().HasIssued()
+}
+
+func (caa *AtomicCounter) update(fn func(*Counter)) {
+	for {
+		old := caa.Load()
+		next := old
+		fn(&next)
+
+		if caa.compareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+func (caa *AtomicCounter) compareAndSwap(old, new Counter) bool {
+	return atomic.CompareAndSwapInt64((*int64)(caa), int64(old), int64(new))
+}
+
+// AtomicTimeout is a Timeout that can be safely shared between goroutines
+// without a mutex. All mutations are performed with compare-and-swap loops on
+// the underlying int64 so they are linearizable.
+type AtomicTimeout int64
+
+func NewAtomicTimeout() *AtomicTimeout {
+	return new(AtomicTimeout)
+}
+
+// Returns a snapshot of the current Timeout value, e.g. for persisting.
+func (caa *AtomicTimeout) Load() Timeout {
+	return Timeout(atomic.LoadInt64((*int64)(caa)))
+}
+
+// Replaces the current value with t, e.g. after loading from storage.
+func (caa *AtomicTimeout) Store(t Timeout) {
+	atomic.StoreInt64((*int64)(caa), int64(t))
+}
+
+func (caa *AtomicTimeout) Lock() {
+	caa.update((*Timeout).Lock)
+}
+
+func (caa *AtomicTimeout) Unlock() {
+	caa.update((*Timeout).Unlock)
+}
+
+func (caa *AtomicTimeout) IsLocked() bool {
+	return caa.Load().IsLocked()
+}
+
+func (caa *AtomicTimeout) IsValid(s SessionCAA, durationSecs int64) bool {
+	return caa.Load().IsValid(s, durationSecs)
+}
+
+func (caa *AtomicTimeout) Validate(s SessionCAA, durationSecs int64) error {
+	return caa.Load().Validate(s, durationSecs)
+}
+
+func (caa *AtomicTimeout) Revoke(expiryTimestamp int64) {
+	caa.update(func(c *Timeout) { c.Revoke(expiryTimestamp) })
+}
+
+func (caa *AtomicTimeout) IsValidAt(s SessionCAA, durationSecs int64, at time.Time) bool {
+	return caa.Load().IsValidAt(s, durationSecs, at)
+}
+
+func (caa *AtomicTimeout) ValidateAt(s SessionCAA, durationSecs int64, at time.Time) error {
+	return caa.Load().ValidateAt(s, durationSecs, at)
+}
+
+func (caa *AtomicTimeout) Issue() SessionCAA {
+	return caa.IssueAt(clock.Now())
+}
+
+func (caa *AtomicTimeout) IssueAt(at time.Time) SessionCAA {
+	for {
+		old := caa.Load()
+		next := old
+		sessionCAA := next.IssueAt(at)
+
+		if caa.compareAndSwap(old, next) {
+			return sessionCAA
+		}
+	}
+}
+
+func (caa *AtomicTimeout) HasIssued() bool {
+	return caa.Load().HasIssued()
+}
+
+func (caa *AtomicTimeout) update(fn func(*Timeout)) {
+	for {
+		old := caa.Load()
+		next := old
+		fn(&next)
+
+		if caa.compareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+func (caa *AtomicTimeout) compareAndSwap(old, new Timeout) bool {
+	return atomic.CompareAndSwapInt64((*int64)(caa), int64(old), int64(new))
+}
+
+func (caa *AtomicCounter) raw() State {
+	return caa.Load().raw()
+}
+
+func (caa *AtomicTimeout) raw() State {
+	return caa.Load().raw()
+}
+
+var _ = CAA(NewAtomicCounter())
+var _ = CAA(NewAtomicTimeout())
@@ -0,0 +1,131 @@
+package compandauth
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/endiangroup/compandauth/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AtomicCounter_BehavesAsCounter(t *testing.T) {
+	caa := NewAtomicCounter()
+	counter := NewCounter()
+
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, counter.Issue(), caa.Issue())
+	}
+
+	caa.Revoke(3)
+	counter.Revoke(3)
+	assert.Equal(t, *counter, caa.Load())
+
+	caa.Lock()
+	counter.Lock()
+	assert.Equal(t, *counter, caa.Load())
+	assert.True(t, caa.IsLocked())
+	assert.Equal(t, ErrLocked, caa.Validate(9, 5))
+
+	caa.Unlock()
+	counter.Unlock()
+	assert.Equal(t, *counter, caa.Load())
+	assert.True(t, caa.IsValid(9, 5))
+	assert.True(t, caa.HasIssued())
+}
+
+func Test_AtomicCounter_IssuesUniqueSessionCAAsConcurrently(t *testing.T) {
+	caa := NewAtomicCounter()
+	goroutines, issuesPerGoroutine := 50, 1000
+
+	var mu sync.Mutex
+	issued := map[SessionCAA]bool{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sessions := make([]SessionCAA, 0, issuesPerGoroutine)
+			for j := 0; j < issuesPerGoroutine; j++ {
+				sessions = append(sessions, caa.Issue())
+			}
+
+			mu.Lock()
+			for _, s := range sessions {
+				issued[s] = true
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, issued, goroutines*issuesPerGoroutine)
+	assert.Equal(t, Counter(goroutines*issuesPerGoroutine), caa.Load())
+}
+
+func Test_AtomicCounter_ConcurrentMutationsAreNotLost(t *testing.T) {
+	caa := NewAtomicCounter()
+	caa.Issue()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(3)
+		go func() { defer wg.Done(); caa.Issue() }()
+		go func() { defer wg.Done(); caa.Revoke(2) }()
+		go func() { defer wg.Done(); caa.Lock(); caa.Unlock() }()
+	}
+	wg.Wait()
+
+	assert.False(t, caa.IsLocked())
+	assert.Equal(t, Counter(1+100+200), caa.Load())
+}
+
+func Test_AtomicTimeout_BehavesAsTimeout(t *testing.T) {
+	now := time.Now()
+	clock.NowForce(now)
+	defer clock.NowReset()
+
+	caa := NewAtomicTimeout()
+	timeout := NewTimeout()
+
+	assert.Equal(t, timeout.Issue(), caa.Issue())
+	assert.Equal(t, *timeout, caa.Load())
+
+	caa.Lock()
+	timeout.Lock()
+	assert.Equal(t, *timeout, caa.Load())
+	assert.Equal(t, ErrLocked, caa.Validate(SessionCAA(now.Unix()), 10))
+
+	caa.Revoke(now.Unix() + 1)
+	timeout.Revoke(now.Unix() + 1)
+	assert.Equal(t, *timeout, caa.Load())
+
+	caa.Unlock()
+	timeout.Unlock()
+	assert.Equal(t, *timeout, caa.Load())
+	assert.False(t, caa.IsLocked())
+	assert.True(t, caa.HasIssued())
+	assert.Equal(t, ErrRevoked, caa.Validate(SessionCAA(now.Unix()), 10))
+}
+
+func Test_AtomicTimeout_ConcurrentMutationsAreNotLost(t *testing.T) {
+	now := time.Now()
+	clock.NowForce(now)
+	defer clock.NowReset()
+
+	caa := NewAtomicTimeout()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() { defer wg.Done(); caa.Issue() }()
+		go func() { defer wg.Done(); caa.Lock(); caa.Unlock() }()
+	}
+	wg.Wait()
+
+	assert.False(t, caa.IsLocked())
+	assert.Equal(t, Timeout(now.Unix()), caa.Load())
+	assert.True(t, caa.IsValid(SessionCAA(now.Unix()), 10))
+}
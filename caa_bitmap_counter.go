@@ -0,0 +1,140 @@
+package compandauth
+
+import "errors"
+
+// BitmapWindow is the number of most recently issued sessions that a
+// BitmapCounter can revoke individually.
+const BitmapWindow = 64
+
+// ErrOutsideWindow is returned by BitmapCounter.RevokeSession when the session
+// is too old to be revoked individually.
+var ErrOutsideWindow = errors.New("compandauth: session is outside the revocation window")
+
+// BitmapCounter is a Counter that can also revoke any of the last
+// BitmapWindow sessions individually (e.g. "log out this device"). Alongside
+// the Counter it keeps a bitmask of revoked sessions, bit 0 being the most
+// recently issued session, which slides along as sessions are issued or
+// revoked in bulk. Both fields must be persisted. As individual revocations
+// are forgotten once they slide out of the window, at most BitmapWindow
+// sessions are ever valid regardless of delta.
+type BitmapCounter struct {
+	Counter Counter
+	Revoked uint64
+}
+
+func NewBitmapCounter() *BitmapCounter {
+	return new(BitmapCounter)
+}
+
+// Locks CAA to prevent validation of session CAA's.
+func (caa *BitmapCounter) Lock() {
+	caa.Counter.Lock()
+}
+
+// Unlocks CAA to allow validation of session CAA's.
+func (caa *BitmapCounter) Unlock() {
+	caa.Counter.Unlock()
+}
+
+func (caa *BitmapCounter) IsLocked() bool {
+	return caa.Counter.IsLocked()
+}
+
+// Indicates if an incoming session CAA is considered valid, see
+// Counter.IsValid, and hasn't been individually revoked.
+func (caa *BitmapCounter) IsValid(s SessionCAA, delta int64) bool {
+	return caa.Validate(s, delta) == nil
+}
+
+// Validate behaves as IsValid but returns the reason a session CAA is
+// considered invalid, see Counter.Validate. delta is clamped to
+// BitmapWindow, so sessions outside the window are always revoked.
+func (caa *BitmapCounter) Validate(s SessionCAA, delta int64) error {
+	if abs(delta) > BitmapWindow {
+		delta = BitmapWindow
+	}
+
+	if err := caa.Counter.Validate(s, delta); err != nil {
+		return err
+	}
+
+	if bit, ok := caa.bit(s); ok && caa.Revoked&bit != 0 {
+		return ErrRevoked
+	}
+
+	return nil
+}
+
+// Invalidates the oldest n sessions, see Counter.Revoke.
+func (caa *BitmapCounter) Revoke(n int64) {
+	if !caa.Counter.HasIssued() {
+		return
+	}
+
+	caa.Counter.Revoke(n)
+	caa.slide(n)
+}
+
+// Invalidates the single session s, which must be one of the last
+// BitmapWindow sessions issued. Revoking a session that is already invalid
+// has no effect.
+func (caa *BitmapCounter) RevokeSession(s SessionCAA) error {
+	switch err := caa.Counter.ValidateStrict(s, BitmapWindow); err {
+	case nil:
+	case ErrRevoked:
+		return ErrOutsideWindow
+	case ErrLocked:
+		// Revocations made whilst locked come into effect when unlocked
+		if err := caa.Counter.abs().ValidateStrict(s, BitmapWindow); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	bit, _ := caa.bit(s)
+	caa.Revoked |= bit
+
+	return nil
+}
+
+// Issues the next CAA value to use in a distributed session, see
+// Counter.Issue.
+func (caa *BitmapCounter) Issue() SessionCAA {
+	s := caa.Counter.Issue()
+	caa.slide(1)
+
+	return s
+}
+
+// Indicates if the CAA has issued at least once, regardless if it has been
+// locked.
+func (caa *BitmapCounter) HasIssued() bool {
+	return caa.Counter.HasIssued()
+}
+
+// Returns the bit representing s, if it is within the window.
+func (caa *BitmapCounter) bit(s SessionCAA) (uint64, bool) {
+	age := int64(caa.Counter.abs()) - 1 - abs(int64(s))
+	if age < 0 || age >= BitmapWindow {
+		return 0, false
+	}
+
+	return 1 << uint(age), true
+}
+
+func (caa *BitmapCounter) slide(n int64) {
+	n = abs(n)
+	if n >= BitmapWindow {
+		caa.Revoked = 0
+		return
+	}
+
+	caa.Revoked <<= uint(n)
+}
+
+func (caa *BitmapCounter) raw() State {
+	return State{Value: int64(caa.Counter), Aux: int64(caa.Revoked), Known: true}
+}
+
+var _ = CAA(NewBitmapCounter())
@@ -0,0 +1,111 @@
+package compandauth
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func issueBitmapCounter(n int) (*BitmapCounter, []SessionCAA) {
+	caa := NewBitmapCounter()
+	sessions := []SessionCAA{}
+
+	for i := 0; i < n; i++ {
+		sessions = append(sessions, caa.Issue())
+	}
+
+	return caa, sessions
+}
+
+func Test_BitmapCounter_RevokeSessionOnlyRevokesThatSession(t *testing.T) {
+	caa, sessions := issueBitmapCounter(5)
+
+	assert.NoError(t, caa.RevokeSession(sessions[2]))
+
+	for i, s := range sessions {
+		if i == 2 {
+			assert.Equal(t, ErrRevoked, caa.Validate(s, 5))
+		} else {
+			assert.NoError(t, caa.Validate(s, 5))
+		}
+	}
+}
+
+func Test_BitmapCounter_RevokedSessionsStayRevokedAsTheWindowSlides(t *testing.T) {
+	caa, sessions := issueBitmapCounter(3)
+	assert.NoError(t, caa.RevokeSession(sessions[1]))
+
+	for i := 0; i < 10; i++ {
+		sessions = append(sessions, caa.Issue())
+	}
+	caa.Revoke(2)
+
+	for i, s := range sessions {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			switch {
+			case i == 1:
+				assert.Equal(t, ErrRevoked, caa.Validate(s, 20))
+			case i >= len(sessions)-(20-2):
+				assert.NoError(t, caa.Validate(s, 20))
+			}
+		})
+	}
+}
+
+func Test_BitmapCounter_RevokedSessionsStayRevokedOnceOutsideWindowWithLargeDelta(t *testing.T) {
+	caa, sessions := issueBitmapCounter(1)
+	assert.NoError(t, caa.RevokeSession(sessions[0]))
+
+	for i := 0; i < BitmapWindow; i++ {
+		sessions = append(sessions, caa.Issue())
+	}
+
+	assert.Equal(t, ErrRevoked, caa.Validate(sessions[0], 100))
+	assert.Equal(t, ErrRevoked, caa.Validate(sessions[0], -100))
+	assert.NoError(t, caa.Validate(sessions[1], 100))
+	assert.NoError(t, caa.Validate(sessions[BitmapWindow], 100))
+}
+
+func Test_BitmapCounter_NewSessionsInheritNoRevocations(t *testing.T) {
+	caa, sessions := issueBitmapCounter(1)
+	assert.NoError(t, caa.RevokeSession(sessions[0]))
+
+	s := caa.Issue()
+
+	assert.NoError(t, caa.Validate(s, 2))
+	assert.Equal(t, uint64(1<<1), caa.Revoked)
+}
+
+func Test_BitmapCounter_RevokeSessionRejectsSessionsItCantRevoke(t *testing.T) {
+	unissued := NewBitmapCounter()
+	assert.Equal(t, ErrNeverIssued, unissued.RevokeSession(0))
+
+	caa, sessions := issueBitmapCounter(BitmapWindow + 1)
+	assert.Equal(t, ErrOutsideWindow, caa.RevokeSession(sessions[0]))
+	assert.NoError(t, caa.RevokeSession(sessions[1]))
+	assert.Equal(t, ErrFutureSession, caa.RevokeSession(sessions[BitmapWindow]+1))
+}
+
+func Test_BitmapCounter_RevokeSessionWhilstLockedAppliesOnUnlock(t *testing.T) {
+	caa, sessions := issueBitmapCounter(3)
+	caa.Lock()
+
+	assert.NoError(t, caa.RevokeSession(sessions[1]))
+	assert.Equal(t, ErrLocked, caa.Validate(sessions[0], 3))
+
+	caa.Unlock()
+	assert.NoError(t, caa.Validate(sessions[0], 3))
+	assert.Equal(t, ErrRevoked, caa.Validate(sessions[1], 3))
+	assert.NoError(t, caa.Validate(sessions[2], 3))
+}
+
+func Test_BitmapCounter_BulkRevokeBeyondWindowClearsBitmap(t *testing.T) {
+	caa, sessions := issueBitmapCounter(3)
+	assert.NoError(t, caa.RevokeSession(sessions[2]))
+
+	caa.Revoke(BitmapWindow)
+
+	assert.Equal(t, uint64(0), caa.Revoked)
+	assert.Equal(t, Counter(3+BitmapWindow), caa.Counter)
+}
@@ -35,12 +35,39 @@ func (caa Counter) IsLocked() bool {
 // be the CAA value retrieved from a distributed session. delta represents
 // number of active distributed sessions you would like to maintain per CAA.
 func (caa Counter) IsValid(s SessionCAA, delta int64) bool {
+	return caa.Validate(s, delta) == nil
+}
+
+// Validate behaves as IsValid but returns the reason a session CAA is
+// considered invalid, one of ErrLocked, ErrNeverIssued or ErrRevoked. Returns
+// nil if the session CAA is valid.
+func (caa Counter) Validate(s SessionCAA, delta int64) error {
+	return caa.validate(s, delta, false)
+}
+
+// ValidateStrict behaves as Validate but also rejects session CAAs the
+// Counter hasn't issued yet with ErrFutureSession. Validate accepts them, as
+// a Counter read from a lagging replica may not reflect the latest Issue.
+func (caa Counter) ValidateStrict(s SessionCAA, delta int64) error {
+	return caa.validate(s, delta, true)
+}
+
+func (caa Counter) validate(s SessionCAA, delta int64, strict bool) error {
 	sessionCAA := abs(int64(s))
 	delta = abs(delta)
 
-	return !caa.IsLocked() &&
-		caa.HasIssued() &&
-		(sessionCAA+delta) >= int64(caa.abs())
+	switch {
+	case caa.IsLocked():
+		return ErrLocked
+	case !caa.HasIssued():
+		return ErrNeverIssued
+	case strict && sessionCAA >= int64(caa.abs()):
+		return ErrFutureSession
+	case (sessionCAA + delta) < int64(caa.abs()):
+		return ErrRevoked
+	}
+
+	return nil
 }
 
 // Invalidates the oldest n sessions. Set n to delta to invalidate all active
@@ -66,6 +93,31 @@ func (caa *Counter) Issue() SessionCAA {
 	return sessionCAA
 }
 
+// Validates old and if valid issues its replacement, e.g. when refreshing a
+// session. If no session older than old is still valid old is also revoked,
+// so it can't be rotated again. As with Revoke the revoked value takes up
+// one of the delta sessions until delta more sessions are issued. Otherwise
+// old can't be revoked without revoking the older valid sessions too, and
+// remains valid until enough newer sessions are issued. Returns the reason
+// old is invalid otherwise, see ValidateStrict.
+func (caa *Counter) Rotate(old SessionCAA, delta int64) (SessionCAA, error) {
+	if err := caa.ValidateStrict(old, delta); err != nil {
+		return 0, err
+	}
+
+	sessionCAA := caa.Issue()
+
+	// The number of revocations needed to invalidate old, any more would
+	// invalidate newer sessions
+	oldCAA := abs(int64(old))
+	n := oldCAA + abs(delta) + 1 - int64(caa.abs())
+	if n == 1 || (oldCAA == 0 && n > 0) {
+		caa.Revoke(n)
+	}
+
+	return sessionCAA, nil
+}
+
 // Indicates if the CAA has issued at least once, regardless if it has been
 // locked.
 func (caa Counter) HasIssued() bool {
@@ -91,4 +143,9 @@ func (caa *Counter) decrement(n int64) {
 	*caa -= Counter(n)
 }
 
+func (caa Counter) raw() State {
+	return State{Value: int64(caa), Known: true}
+}
+
 var _ = CAA(NewCounter())
+var _ = Rotator(NewCounter())
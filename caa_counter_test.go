@@ -1,6 +1,7 @@
 package compandauth
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"testing"
@@ -142,6 +143,43 @@ func Test_IsValid_ReturnsTrueIfSessionCAAPlusDeltaIsGreaterThanOrEqualToCounterC
 	assert.True(t, setCounterCAA(50).IsValid(45, 10))
 }
 
+func Test_IsValid_ReturnsTrueIfSessionCAAIsAheadOfCounterCAA(t *testing.T) {
+	assert.True(t, setCounterCAA(1).IsValid(1, 1))
+	assert.True(t, setCounterCAA(50).IsValid(60, 10))
+}
+
+func Test_ValidateStrict_ReturnsErrFutureSessionIfSessionCAAIsAheadOfCounterCAA(t *testing.T) {
+	assert.Equal(t, ErrFutureSession, setCounterCAA(1).ValidateStrict(1, 1))
+	assert.Equal(t, ErrFutureSession, setCounterCAA(50).ValidateStrict(60, 10))
+	assert.Equal(t, ErrRevoked, setCounterCAA(5).ValidateStrict(3, 1))
+	assert.NoError(t, setCounterCAA(5).ValidateStrict(4, 1))
+}
+
+func Test_Validate_ReturnsReasonSessionCAAIsInvalidForCounterCAA(t *testing.T) {
+	tests := []struct {
+		CAA         *Counter
+		SessionCAA  SessionCAA
+		Delta       int64
+		ExpectedErr error
+	}{
+		{CAA: setCounterCAA(-5), SessionCAA: 4, Delta: 1, ExpectedErr: ErrLocked},
+		{CAA: setCounterCAA(0), SessionCAA: 0, Delta: 1, ExpectedErr: ErrNeverIssued},
+		{CAA: setCounterCAA(5), SessionCAA: 5, Delta: 1, ExpectedErr: nil},
+		{CAA: setCounterCAA(5), SessionCAA: 3, Delta: 1, ExpectedErr: ErrRevoked},
+		{CAA: setCounterCAA(5), SessionCAA: 4, Delta: 1, ExpectedErr: nil},
+		{CAA: setCounterCAA(5), SessionCAA: 0, Delta: 5, ExpectedErr: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%+v", test), func(t *testing.T) {
+			err := test.CAA.Validate(test.SessionCAA, test.Delta)
+
+			assert.True(t, errors.Is(err, test.ExpectedErr))
+			assert.Equal(t, err == nil, test.CAA.IsValid(test.SessionCAA, test.Delta))
+		})
+	}
+}
+
 func Test_Issue_ReturnsNextSessionCAAValueAndIncrementsCounterCAA(t *testing.T) {
 	tests := []struct {
 		CAA                *Counter
@@ -277,3 +315,75 @@ func Test_Revoke_ReturnsCounterCAAWithRevocations(t *testing.T) {
 		})
 	}
 }
+
+func Test_Rotate_IssuesReplacementAndRevokesOldestValidCounterSession(t *testing.T) {
+	tests := []struct {
+		CAA         *Counter
+		Old         SessionCAA
+		Delta       int64
+		ExpectedCAA *Counter
+		StillValid  []SessionCAA
+	}{
+		// Only session
+		{CAA: setCounterCAA(1), Old: 0, Delta: 1, ExpectedCAA: setCounterCAA(2)},
+		{CAA: setCounterCAA(1), Old: 0, Delta: 3, ExpectedCAA: setCounterCAA(4)},
+		// Oldest of several
+		{CAA: setCounterCAA(5), Old: 3, Delta: 3, ExpectedCAA: setCounterCAA(7), StillValid: []SessionCAA{4}},
+		// Invalidated by issuing the replacement
+		{CAA: setCounterCAA(3), Old: 0, Delta: 3, ExpectedCAA: setCounterCAA(4), StillValid: []SessionCAA{1, 2}},
+		// Older sessions still valid, so can't be revoked
+		{CAA: setCounterCAA(5), Old: 3, Delta: 4, ExpectedCAA: setCounterCAA(6), StillValid: []SessionCAA{2, 3, 4}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%+v", test), func(t *testing.T) {
+			next := SessionCAA(test.CAA.abs())
+			s, err := test.CAA.Rotate(test.Old, test.Delta)
+
+			assert.NoError(t, err)
+			assert.Equal(t, next, s)
+			assert.Equal(t, test.ExpectedCAA, test.CAA)
+			assert.NoError(t, test.CAA.Validate(s, test.Delta))
+			for _, valid := range test.StillValid {
+				assert.NoError(t, test.CAA.Validate(valid, test.Delta))
+			}
+			if !containsSessionCAA(test.StillValid, test.Old) {
+				assert.Equal(t, ErrRevoked, test.CAA.Validate(test.Old, test.Delta))
+			}
+		})
+	}
+}
+
+func Test_Rotate_DoesNotIssueForInvalidCounterSession(t *testing.T) {
+	tests := []struct {
+		CAA         *Counter
+		Old         SessionCAA
+		ExpectedErr error
+	}{
+		{CAA: setCounterCAA(0), Old: 0, ExpectedErr: ErrNeverIssued},
+		{CAA: setCounterCAA(-3), Old: 2, ExpectedErr: ErrLocked},
+		{CAA: setCounterCAA(5), Old: 1, ExpectedErr: ErrRevoked},
+		{CAA: setCounterCAA(5), Old: 5, ExpectedErr: ErrFutureSession},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%+v", test), func(t *testing.T) {
+			before := *test.CAA
+			s, err := test.CAA.Rotate(test.Old, 2)
+
+			assert.Equal(t, test.ExpectedErr, err)
+			assert.Equal(t, SessionCAA(0), s)
+			assert.Equal(t, before, *test.CAA)
+		})
+	}
+}
+
+func containsSessionCAA(sessions []SessionCAA, s SessionCAA) bool {
+	for _, session := range sessions {
+		if session == s {
+			return true
+		}
+	}
+
+	return false
+}
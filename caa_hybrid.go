@@ -0,0 +1,132 @@
+package compandauth
+
+import (
+	"time"
+
+	"github.com/endiangroup/compandauth/clock"
+)
+
+const (
+	hybridCounterBits = 28
+	hybridCounterMask = 1<<hybridCounterBits - 1
+)
+
+// Hybrid pairs a Counter and a Timeout so that a session is only valid if it
+// is one of the last delta sessions issued AND was issued within MaxAgeSecs.
+// Both halves are issued, locked and unlocked together.
+//
+// The session CAAs it issues pack the unix timestamp in seconds into the high
+// bits and the low 28 bits of the Counter into the low bits. Counters are
+// compared modulo 2^28, which is unambiguous as long as delta is less than
+// 2^27 and fewer than 2^28 sessions are issued within MaxAgeSecs.
+//
+// MaxAgeSecs and Leeway are configuration rather than state, so needn't be
+// persisted along with the Counter and Timeout. Leeway is the number of
+// seconds clocks across servers may be out of sync by, see
+// Timeout.ValidateAtWithLeeway. With the default Leeway of 0 sessions issued
+// after now are accepted, as Timeout.Validate does.
+type Hybrid struct {
+	Counter    Counter
+	Timeout    Timeout
+	MaxAgeSecs int64
+	Leeway     int64
+}
+
+func NewHybrid(maxAge time.Duration) *Hybrid {
+	return &Hybrid{MaxAgeSecs: ToSeconds(maxAge)}
+}
+
+// Locks both halves to prevent validation of session CAA's.
+func (caa *Hybrid) Lock() {
+	caa.Counter.Lock()
+	caa.Timeout.Lock()
+}
+
+// Unlocks both halves to allow validation of session CAA's.
+func (caa *Hybrid) Unlock() {
+	caa.Counter.Unlock()
+	caa.Timeout.Unlock()
+}
+
+func (caa *Hybrid) IsLocked() bool {
+	return caa.Counter.IsLocked()
+}
+
+// Indicates if a session CAA is one of the last delta sessions issued and was
+// issued within MaxAgeSecs.
+func (caa *Hybrid) IsValid(s SessionCAA, delta int64) bool {
+	return caa.Validate(s, delta) == nil
+}
+
+// Validate behaves as IsValid but returns the reason a session CAA is
+// considered invalid, see Counter.Validate and Timeout.Validate.
+func (caa *Hybrid) Validate(s SessionCAA, delta int64) error {
+	return caa.ValidateAt(s, delta, clock.Now())
+}
+
+// Validate at the given time rather than clock.Now, see Validate.
+func (caa *Hybrid) ValidateAt(s SessionCAA, delta int64, at time.Time) error {
+	timestamp, counter := splitHybrid(s)
+
+	if err := caa.Timeout.validateAt(timestamp, caa.MaxAgeSecs, caa.Leeway, at, caa.Leeway != 0); err != nil {
+		return err
+	}
+
+	// Unlike the timestamp the counter can't be ahead, it is incremented on
+	// every Issue
+	switch issuedSince := (int64(caa.Counter.abs()) - counter) & hybridCounterMask; {
+	case issuedSince == 0:
+		return ErrFutureSession
+	case issuedSince > abs(delta):
+		return ErrRevoked
+	}
+
+	return nil
+}
+
+// Invalidates the oldest n sessions, see Counter.Revoke.
+func (caa *Hybrid) Revoke(n int64) {
+	caa.Counter.Revoke(n)
+}
+
+// Invalidates all sessions issued before expiryTimestamp, see
+// Timeout.Revoke.
+func (caa *Hybrid) RevokeBefore(expiryTimestamp int64) {
+	caa.Timeout.Revoke(expiryTimestamp)
+}
+
+// Issues the next CAA value to use in a distributed session from both
+// halves.
+func (caa *Hybrid) Issue() SessionCAA {
+	return caa.IssueAt(clock.Now())
+}
+
+// Issue at the given time rather than clock.Now, see Issue.
+func (caa *Hybrid) IssueAt(at time.Time) SessionCAA {
+	counter := caa.Counter.Issue()
+	timestamp := caa.Timeout.IssueAt(at)
+
+	return joinHybrid(timestamp, int64(counter))
+}
+
+// Indicates if the CAA has issued at least once, regardless if it has been
+// locked.
+func (caa *Hybrid) HasIssued() bool {
+	return caa.Counter.HasIssued()
+}
+
+func joinHybrid(timestamp SessionCAA, counter int64) SessionCAA {
+	return timestamp<<hybridCounterBits | SessionCAA(counter&hybridCounterMask)
+}
+
+func splitHybrid(s SessionCAA) (SessionCAA, int64) {
+	s = SessionCAA(abs(int64(s)))
+
+	return s >> hybridCounterBits, int64(s & hybridCounterMask)
+}
+
+func (caa *Hybrid) raw() State {
+	return State{Value: int64(caa.Counter), Aux: int64(caa.Timeout), Known: true}
+}
+
+var _ = CAA(NewHybrid(time.Minute))
@@ -0,0 +1,131 @@
+package compandauth
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/endiangroup/compandauth/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Hybrid_IssuesPackedTimestampAndCounter(t *testing.T) {
+	at := time.Unix(1500000000, 0)
+	caa := NewHybrid(time.Minute)
+
+	caa.IssueAt(at)
+	s := caa.IssueAt(at)
+
+	timestamp, counter := splitHybrid(s)
+	assert.Equal(t, SessionCAA(at.Unix()), timestamp)
+	assert.Equal(t, int64(1), counter)
+	assert.Equal(t, Counter(2), caa.Counter)
+	assert.Equal(t, Timeout(at.Unix()), caa.Timeout)
+}
+
+func Test_Hybrid_EnforcesBothDeltaAndMaxAge(t *testing.T) {
+	fake := clock.NewFake(time.Unix(1500000000, 0))
+	caa := NewHybrid(30 * time.Second)
+
+	sessions := []SessionCAA{}
+	for i := 0; i < 5; i++ {
+		sessions = append(sessions, caa.IssueAt(fake.Now()))
+		fake.Advance(10 * time.Second)
+	}
+	// Issued at 0s, 10s, 20s, 30s and 40s, now 50s
+
+	tests := []struct {
+		Session     int
+		Delta       int64
+		ExpectedErr error
+	}{
+		{Session: 4, Delta: 1, ExpectedErr: nil},
+		{Session: 3, Delta: 1, ExpectedErr: ErrRevoked},
+		{Session: 3, Delta: 2, ExpectedErr: nil},
+		{Session: 2, Delta: 3, ExpectedErr: nil},
+		{Session: 1, Delta: 4, ExpectedErr: ErrExpired},
+		{Session: 0, Delta: 1, ExpectedErr: ErrExpired},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%+v", test), func(t *testing.T) {
+			assert.Equal(t, test.ExpectedErr, caa.ValidateAt(sessions[test.Session], test.Delta, fake.Now()))
+		})
+	}
+}
+
+func Test_Hybrid_RejectsSessionsNotYetIssued(t *testing.T) {
+	at := time.Unix(1500000000, 0)
+	caa := NewHybrid(time.Minute)
+	s := caa.IssueAt(at)
+
+	assert.Equal(t, ErrFutureSession, caa.ValidateAt(joinHybrid(SessionCAA(at.Unix()), 1), 5, at))
+	assert.NoError(t, caa.ValidateAt(s, 5, at))
+}
+
+func Test_Hybrid_ToleratesSkewUnlessLeewayIsSet(t *testing.T) {
+	at := time.Unix(1500000000, 0)
+	caa := NewHybrid(time.Minute)
+	s := caa.IssueAt(at.Add(2 * time.Second))
+
+	assert.NoError(t, caa.ValidateAt(s, 5, at))
+
+	caa.Leeway = 2
+	assert.NoError(t, caa.ValidateAt(s, 5, at))
+
+	caa.Leeway = 1
+	assert.Equal(t, ErrFutureSession, caa.ValidateAt(s, 5, at))
+}
+
+func Test_Hybrid_LockAndUnlockBothHalves(t *testing.T) {
+	at := time.Unix(1500000000, 0)
+	caa := NewHybrid(time.Minute)
+	s := caa.IssueAt(at)
+
+	caa.Lock()
+	assert.True(t, caa.IsLocked())
+	assert.True(t, caa.Counter.IsLocked())
+	assert.True(t, caa.Timeout.IsLocked())
+	assert.Equal(t, ErrLocked, caa.ValidateAt(s, 1, at))
+
+	caa.Unlock()
+	assert.False(t, caa.Counter.IsLocked())
+	assert.False(t, caa.Timeout.IsLocked())
+	assert.NoError(t, caa.ValidateAt(s, 1, at))
+}
+
+func Test_Hybrid_RevokesByCountOrTimestamp(t *testing.T) {
+	at := time.Unix(1500000000, 0)
+	caa := NewHybrid(time.Minute)
+	first := caa.IssueAt(at)
+	second := caa.IssueAt(at.Add(time.Second))
+
+	caa.Revoke(1)
+	assert.Equal(t, ErrRevoked, caa.ValidateAt(first, 2, at.Add(time.Second)))
+	assert.NoError(t, caa.ValidateAt(second, 2, at.Add(time.Second)))
+
+	caa.RevokeBefore(at.Add(2 * time.Second).Unix())
+	assert.Equal(t, ErrRevoked, caa.ValidateAt(second, 2, at.Add(2*time.Second)))
+}
+
+func Test_Hybrid_ComparesCountersAcrossWrapAround(t *testing.T) {
+	at := time.Unix(1500000000, 0)
+	caa := NewHybrid(time.Minute)
+	caa.Counter = hybridCounterMask - 1
+	caa.Timeout = Timeout(at.Unix())
+
+	first := caa.IssueAt(at)
+	second := caa.IssueAt(at)
+	third := caa.IssueAt(at)
+
+	_, counter := splitHybrid(third)
+	assert.Equal(t, int64(0), counter)
+	assert.NoError(t, caa.ValidateAt(first, 3, at))
+	assert.NoError(t, caa.ValidateAt(second, 2, at))
+	assert.Equal(t, ErrRevoked, caa.ValidateAt(first, 2, at))
+}
+
+func Test_Hybrid_NeverIssuedIsInvalid(t *testing.T) {
+	assert.Equal(t, ErrNeverIssued, NewHybrid(time.Minute).Validate(0, 1))
+	assert.False(t, NewHybrid(time.Minute).HasIssued())
+}
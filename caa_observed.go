@@ -0,0 +1,170 @@
+package compandauth
+
+import "database/sql/driver"
+
+// Event is emitted by Observed to its Observers, it is one of Issued,
+// Revoked, Locked, Unlocked or ValidationFailed.
+type Event interface {
+	event()
+}
+
+// State is the raw state of a CAA as it would be persisted. Value is the
+// int64 the CAA is stored as (e.g. the Counter) and Aux the second of the
+// pair for CAAs stored as a pair of int64s (e.g. Sliding.LastActive), 0
+// otherwise. Known is false if the state of the CAA can't be determined, in
+// which case Value and Aux are meaningless.
+type State struct {
+	Value int64
+	Aux   int64
+	Known bool
+}
+
+// Transition holds the raw CAA state before and after a mutation.
+type Transition struct {
+	Before State
+	After  State
+}
+
+// Emitted after a session CAA has been issued.
+type Issued struct {
+	Transition
+	Session SessionCAA
+}
+
+// Emitted after sessions have been revoked, N is the number of sessions for
+// a Counter or the expiry timestamp for a Timeout.
+type Revoked struct {
+	Transition
+	N int64
+}
+
+// Emitted after the CAA has been locked.
+type Locked struct {
+	Transition
+}
+
+// Emitted after the CAA has been unlocked.
+type Unlocked struct {
+	Transition
+}
+
+// Emitted when a session CAA fails validation, Reason is the error returned
+// by Validate and Raw the CAA state it was validated against.
+type ValidationFailed struct {
+	Session SessionCAA
+	N       int64
+	Reason  error
+	Raw     State
+}
+
+func (Issued) event()           {}
+func (Revoked) event()          {}
+func (Locked) event()           {}
+func (Unlocked) event()         {}
+func (ValidationFailed) event() {}
+
+type Observer interface {
+	Observe(Event)
+}
+
+// ObserverFunc adapts a function to an Observer.
+type ObserverFunc func(Event)
+
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+// Observed wraps a CAA and emits an Event to each of its Observers for every
+// state transition and failed validation, e.g. to feed an audit log. Events
+// are emitted synchronously on the calling goroutine. Observed is not safe
+// for concurrent use, wrap it with ThreadSafe if required.
+type Observed struct {
+	CAA
+	observers []Observer
+}
+
+func NewObserved(caa CAA, observers ...Observer) *Observed {
+	return &Observed{
+		CAA:       caa,
+		observers: observers,
+	}
+}
+
+func (o *Observed) AddObserver(observer Observer) {
+	o.observers = append(o.observers, observer)
+}
+
+func (o *Observed) Lock() {
+	before := rawState(o.CAA)
+	o.CAA.Lock()
+	o.emit(Locked{Transition{before, rawState(o.CAA)}})
+}
+
+func (o *Observed) Unlock() {
+	before := rawState(o.CAA)
+	o.CAA.Unlock()
+	o.emit(Unlocked{Transition{before, rawState(o.CAA)}})
+}
+
+func (o *Observed) IsValid(s SessionCAA, n int64) bool {
+	return o.Validate(s, n) == nil
+}
+
+func (o *Observed) Validate(s SessionCAA, n int64) error {
+	err := o.CAA.Validate(s, n)
+	if err != nil {
+		o.emit(ValidationFailed{Session: s, N: n, Reason: err, Raw: rawState(o.CAA)})
+	}
+
+	return err
+}
+
+func (o *Observed) Revoke(n int64) {
+	before := rawState(o.CAA)
+	o.CAA.Revoke(n)
+	o.emit(Revoked{Transition{before, rawState(o.CAA)}, n})
+}
+
+func (o *Observed) Issue() SessionCAA {
+	before := rawState(o.CAA)
+	sessionCAA := o.CAA.Issue()
+	o.emit(Issued{Transition{before, rawState(o.CAA)}, sessionCAA})
+
+	return sessionCAA
+}
+
+func (o *Observed) emit(e Event) {
+	for _, observer := range o.observers {
+		observer.Observe(e)
+	}
+}
+
+func (o *Observed) raw() State {
+	return rawState(o.CAA)
+}
+
+// rawer is implemented by the CAAs of this package to expose their raw
+// state.
+type rawer interface {
+	raw() State
+}
+
+// Returns the raw state of caa. CAAs from outside this package are supported
+// if they implement driver.Valuer returning an int64, otherwise the state is
+// unknown.
+func rawState(caa CAA) State {
+	switch c := caa.(type) {
+	case rawer:
+		return c.raw()
+	case driver.Valuer:
+		if v, err := c.Value(); err == nil {
+			if i, ok := v.(int64); ok {
+				return State{Value: i, Known: true}
+			}
+		}
+	}
+
+	return State{}
+}
+
+var _ = CAA(NewObserved(NewCounter()))
@@ -0,0 +1,144 @@
+package compandauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/endiangroup/compandauth/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingObserver struct {
+	events []Event
+}
+
+func (r *recordingObserver) Observe(e Event) {
+	r.events = append(r.events, e)
+}
+
+func known(v int64) State {
+	return State{Value: v, Known: true}
+}
+
+func transition(before, after int64) Transition {
+	return Transition{Before: known(before), After: known(after)}
+}
+
+func Test_Observed_EmitsEventsForCounterTransitions(t *testing.T) {
+	recorder := &recordingObserver{}
+	caa := NewObserved(NewCounter(), recorder)
+
+	s := caa.Issue()
+	caa.Revoke(2)
+	caa.Lock()
+	caa.Unlock()
+
+	assert.Equal(t, []Event{
+		Issued{Transition: transition(0, 1), Session: s},
+		Revoked{Transition: transition(1, 3), N: 2},
+		Locked{Transition: transition(3, -3)},
+		Unlocked{Transition: transition(-3, 3)},
+	}, recorder.events)
+}
+
+func Test_Observed_EmitsEventsForTimeoutTransitions(t *testing.T) {
+	now := time.Now()
+	clock.NowForce(now)
+	defer clock.NowReset()
+
+	recorder := &recordingObserver{}
+	caa := NewObserved(NewAtomicTimeout(), recorder)
+
+	s := caa.Issue()
+	caa.Revoke(now.Unix() + 10)
+
+	assert.Equal(t, []Event{
+		Issued{Transition: transition(0, now.Unix()), Session: s},
+		Revoked{Transition: transition(now.Unix(), now.Unix()+10), N: now.Unix() + 10},
+	}, recorder.events)
+}
+
+func Test_Observed_EmitsValidationFailedWithReason(t *testing.T) {
+	recorder := &recordingObserver{}
+	caa := NewObserved(NewCounter(), recorder)
+
+	assert.False(t, caa.IsValid(0, 1))
+	s := caa.Issue()
+	assert.True(t, caa.IsValid(s, 1))
+	caa.Lock()
+	assert.Equal(t, ErrLocked, caa.Validate(s, 1))
+
+	assert.Equal(t, []Event{
+		ValidationFailed{Session: 0, N: 1, Reason: ErrNeverIssued, Raw: known(0)},
+		Issued{Transition: transition(0, 1), Session: s},
+		Locked{Transition: transition(1, -1)},
+		ValidationFailed{Session: s, N: 1, Reason: ErrLocked, Raw: known(-1)},
+	}, recorder.events)
+}
+
+func Test_Observed_EmitsToEveryObserverInOrder(t *testing.T) {
+	var order []string
+	caa := NewObserved(NewCounter(), ObserverFunc(func(Event) { order = append(order, "first") }))
+	caa.AddObserver(ObserverFunc(func(Event) { order = append(order, "second") }))
+
+	caa.Issue()
+
+	assert.Equal(t, []string{"first", "second"}, order)
+}
+
+func Test_Observed_EmitsBothValuesOfCAAsStoredAsAPair(t *testing.T) {
+	recorder := &recordingObserver{}
+	caa := NewObserved(NewRefreshFamily(ReuseRevoke), recorder)
+
+	caa.Issue()
+	caa.Issue()
+
+	assert.Equal(t, Transition{
+		Before: State{Value: 1, Aux: 0, Known: true},
+		After:  State{Value: 2, Aux: 1, Known: true},
+	}, recorder.events[1].(Issued).Transition)
+}
+
+func Test_Observed_EmitsStateOfEveryCAAInThisPackage(t *testing.T) {
+	caas := []CAA{
+		NewCounter(), NewTimeout(), NewTimeoutMillis(), NewTimeoutWithClock(clock.Real{}),
+		NewSliding(0), NewAtomicCounter(), NewAtomicTimeout(), NewHybrid(time.Minute),
+		NewBitmapCounter(), NewRefreshFamily(ReuseLock), NewCounterRecord(),
+		NewThreadSafe(NewCounter()), NewObserved(NewCounter()),
+	}
+
+	for _, c := range caas {
+		recorder := &recordingObserver{}
+		caa := NewObserved(c, recorder)
+		caa.Issue()
+
+		issued := recorder.events[0].(Issued)
+		assert.True(t, issued.Before.Known, "%T", c)
+		assert.True(t, issued.After.Known, "%T", c)
+		assert.NotEqual(t, issued.Before, issued.After, "%T", c)
+	}
+}
+
+func Test_Observed_EmitsUnknownStateForSlots(t *testing.T) {
+	recorder := &recordingObserver{}
+	caa := NewObserved(NewSlots(2), recorder)
+
+	caa.Issue()
+	caa.Revoke(1)
+
+	assert.Equal(t, Transition{}, recorder.events[0].(Issued).Transition)
+	assert.Equal(t, Transition{}, recorder.events[1].(Revoked).Transition)
+}
+
+type opaqueCAA struct {
+	CAA
+}
+
+func Test_Observed_EmitsUnknownStateForOtherCAAs(t *testing.T) {
+	recorder := &recordingObserver{}
+	caa := NewObserved(opaqueCAA{NewCounter()}, recorder)
+
+	caa.Issue()
+
+	assert.Equal(t, Transition{}, recorder.events[0].(Issued).Transition)
+}
@@ -0,0 +1,252 @@
+package compandauth
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/endiangroup/compandauth/clock"
+)
+
+// ErrMalformedRecord is returned when unmarshalling a Record with an unknown
+// type or reason.
+var ErrMalformedRecord = errors.New("compandauth: malformed record")
+
+// Reason is why a CAA was revoked or locked.
+type Reason string
+
+const (
+	ReasonUnspecified         Reason = ""
+	ReasonUserLogout          Reason = "user-logout"
+	ReasonAdmin               Reason = "admin"
+	ReasonPasswordChange      Reason = "password-change"
+	ReasonSuspectedCompromise Reason = "suspected-compromise"
+)
+
+// Indicates if r is one of the defined reasons.
+func (r Reason) IsKnown() bool {
+	switch r {
+	case ReasonUnspecified, ReasonUserLogout, ReasonAdmin, ReasonPasswordChange, ReasonSuspectedCompromise:
+		return true
+	}
+
+	return false
+}
+
+// Change describes when, why and by whom a CAA was last revoked or locked.
+type Change struct {
+	At     time.Time `json:"at"`
+	Reason Reason    `json:"reason,omitempty"`
+	Actor  string    `json:"actor,omitempty"`
+}
+
+// Indicates if no change has been recorded.
+func (c Change) IsZero() bool {
+	return c.At.IsZero() && c.Reason == ReasonUnspecified && c.Actor == ""
+}
+
+// Record is a Counter, Timeout or TimeoutMillis that also remembers the last
+// time it was revoked and locked, and why. It answers "why was I logged out
+// and when?". Validation is dispatched straight to the underlying type so
+// costs the same as the plain types. Revocations and locks are only recorded
+// once the Record has issued, as until then they have no effect, and locks
+// only when it isn't already locked, so locking a locked Record keeps the
+// original lock's reason.
+type Record struct {
+	kind       Kind
+	value      int64
+	lastRevoke Change
+	lastLock   Change
+}
+
+func NewCounterRecord() *Record {
+	return &Record{kind: KindCounter}
+}
+
+func NewTimeoutRecord() *Record {
+	return &Record{kind: KindTimeout}
+}
+
+func NewTimeoutMillisRecord() *Record {
+	return &Record{kind: KindTimeoutMillis}
+}
+
+// Returns the kind of the underlying CAA.
+func (r *Record) Kind() Kind {
+	return r.kind
+}
+
+// Returns the raw value of the underlying CAA.
+func (r *Record) Raw() int64 {
+	return r.value
+}
+
+// Returns the last revocation, zero if never revoked.
+func (r *Record) LastRevoke() Change {
+	return r.lastRevoke
+}
+
+// Returns the last lock, zero if never locked.
+func (r *Record) LastLock() Change {
+	return r.lastLock
+}
+
+// Locks CAA to prevent validation of session CAA's, without a reason.
+func (r *Record) Lock() {
+	r.LockBecause(ReasonUnspecified, "")
+}
+
+// Locks CAA recording why and by whom.
+func (r *Record) LockBecause(reason Reason, actor string) {
+	if r.IsLocked() || !r.HasIssued() || r.caa() == nil {
+		return
+	}
+
+	r.caa().Lock()
+	r.lastLock = newChange(reason, actor)
+}
+
+// Unlocks CAA to allow validation of session CAA's. The last lock is kept.
+func (r *Record) Unlock() {
+	if caa := r.caa(); caa != nil {
+		caa.Unlock()
+	}
+}
+
+func (r *Record) IsLocked() bool {
+	return r.value < 0
+}
+
+func (r *Record) IsValid(s SessionCAA, delta int64) bool {
+	return r.Validate(s, delta) == nil
+}
+
+// Validate behaves as the underlying type's Validate.
+func (r *Record) Validate(s SessionCAA, delta int64) error {
+	switch r.kind {
+	case KindCounter:
+		return Counter(r.value).Validate(s, delta)
+	case KindTimeout:
+		return Timeout(r.value).Validate(s, delta)
+	case KindTimeoutMillis:
+		return TimeoutMillis(r.value).Validate(s, delta)
+	}
+
+	return ErrNeverIssued
+}
+
+// Revokes as the underlying type's Revoke, without a reason.
+func (r *Record) Revoke(n int64) {
+	r.RevokeBecause(n, ReasonUnspecified, "")
+}
+
+// Revokes as the underlying type's Revoke recording why and by whom.
+func (r *Record) RevokeBecause(n int64, reason Reason, actor string) {
+	if !r.HasIssued() || r.caa() == nil {
+		return
+	}
+
+	r.caa().Revoke(n)
+	r.lastRevoke = newChange(reason, actor)
+}
+
+func (r *Record) Issue() SessionCAA {
+	if caa := r.caa(); caa != nil {
+		return caa.Issue()
+	}
+
+	return 0
+}
+
+func (r *Record) HasIssued() bool {
+	return r.value != 0
+}
+
+type recordJSON struct {
+	Type       Kind    `json:"type"`
+	Value      int64   `json:"value"`
+	LastRevoke *Change `json:"last_revoke,omitempty"`
+	LastLock   *Change `json:"last_lock,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, omitting changes never recorded.
+func (r *Record) MarshalJSON() ([]byte, error) {
+	rj := recordJSON{Type: r.kind, Value: r.value}
+	if !r.lastRevoke.IsZero() {
+		rj.LastRevoke = &r.lastRevoke
+	}
+	if !r.lastLock.IsZero() {
+		rj.LastLock = &r.lastLock
+	}
+
+	return json.Marshal(rj)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, returning ErrMalformedRecord for
+// unknown types or reasons.
+func (r *Record) UnmarshalJSON(b []byte) error {
+	var rj recordJSON
+	if err := json.Unmarshal(b, &rj); err != nil {
+		return err
+	}
+
+	if newKindCAA(rj.Type, new(int64)) == nil {
+		return fmt.Errorf("%w: unknown type %q", ErrMalformedRecord, rj.Type)
+	}
+
+	var lastRevoke, lastLock Change
+	for _, c := range []struct {
+		src *Change
+		dst *Change
+	}{{rj.LastRevoke, &lastRevoke}, {rj.LastLock, &lastLock}} {
+		if c.src == nil {
+			continue
+		}
+		if !c.src.Reason.IsKnown() {
+			return fmt.Errorf("%w: unknown reason %q", ErrMalformedRecord, c.src.Reason)
+		}
+		*c.dst = *c.src
+	}
+
+	r.kind, r.value, r.lastRevoke, r.lastLock = rj.Type, rj.Value, lastRevoke, lastLock
+	return nil
+}
+
+// Value implements driver.Valuer, storing the Record as JSON.
+func (r *Record) Value() (driver.Value, error) {
+	return r.MarshalJSON()
+}
+
+// Scan implements sql.Scanner. The Record must be stored as JSON, see Value,
+// a NULL is considered a Record that has never issued or recorded a change,
+// keeping its kind.
+func (r *Record) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		r.value, r.lastRevoke, r.lastLock = 0, Change{}, Change{}
+		return nil
+	case []byte:
+		return r.UnmarshalJSON(v)
+	case string:
+		return r.UnmarshalJSON([]byte(v))
+	}
+
+	return fmt.Errorf("compandauth: scanning Record: unsupported type %T", src)
+}
+
+// Returns the underlying CAA viewing r's value, nil if r has no type.
+func (r *Record) caa() CAA {
+	return newKindCAA(r.kind, &r.value)
+}
+
+func newChange(reason Reason, actor string) Change {
+	return Change{At: clock.Now().UTC(), Reason: reason, Actor: actor}
+}
+
+func (r *Record) raw() State {
+	return State{Value: r.value, Known: true}
+}
+
+var _ = CAA(NewCounterRecord())
@@ -0,0 +1,144 @@
+package compandauth
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/endiangroup/compandauth/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Record_RecordsReasonForRevokeAndLock(t *testing.T) {
+	now := time.Unix(1500000000, 0).UTC()
+	clock.NowForce(now)
+	defer clock.NowReset()
+
+	caa := NewCounterRecord()
+	s := caa.Issue()
+	caa.Issue()
+
+	caa.RevokeBecause(2, ReasonPasswordChange, "user:1")
+	clock.NowForce(now.Add(time.Minute))
+	caa.LockBecause(ReasonSuspectedCompromise, "admin:7")
+
+	assert.Equal(t, Change{At: now, Reason: ReasonPasswordChange, Actor: "user:1"}, caa.LastRevoke())
+	assert.Equal(t, Change{At: now.Add(time.Minute), Reason: ReasonSuspectedCompromise, Actor: "admin:7"}, caa.LastLock())
+	assert.Equal(t, ErrLocked, caa.Validate(s, 1))
+
+	caa.Unlock()
+	assert.Equal(t, ErrRevoked, caa.Validate(s, 1))
+	assert.Equal(t, ReasonSuspectedCompromise, caa.LastLock().Reason)
+}
+
+func Test_Record_OnlyRecordsRevokesOnceIssuedAndLocksWhenUnlocked(t *testing.T) {
+	clock.NowForce(time.Unix(1500000000, 0))
+	defer clock.NowReset()
+
+	caa := NewCounterRecord()
+	caa.RevokeBecause(1, ReasonAdmin, "admin:7")
+	assert.True(t, caa.LastRevoke().IsZero())
+	caa.LockBecause(ReasonAdmin, "admin:7")
+	assert.True(t, caa.LastLock().IsZero())
+	assert.False(t, caa.IsLocked())
+
+	caa.Issue()
+	caa.LockBecause(ReasonUserLogout, "user:1")
+	caa.LockBecause(ReasonAdmin, "admin:7")
+	assert.Equal(t, ReasonUserLogout, caa.LastLock().Reason)
+}
+
+func Test_Record_ValidatesAsUnderlyingType(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	clock.NowForce(now)
+	defer clock.NowReset()
+
+	tests := []struct {
+		Record *Record
+		CAA    CAA
+	}{
+		{Record: NewCounterRecord(), CAA: NewCounter()},
+		{Record: NewTimeoutRecord(), CAA: NewTimeout()},
+		{Record: NewTimeoutMillisRecord(), CAA: NewTimeoutMillis()},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.Record.Kind()), func(t *testing.T) {
+			assert.Equal(t, test.CAA.Validate(0, 1), test.Record.Validate(0, 1))
+
+			s := test.Record.Issue()
+			assert.Equal(t, test.CAA.Issue(), s)
+			assert.Equal(t, rawState(test.CAA).Value, test.Record.Raw())
+			assert.NoError(t, test.Record.Validate(s, 1))
+
+			clock.NowForce(now.Add(2 * time.Second))
+			assert.Equal(t, test.CAA.Validate(s, 1), test.Record.Validate(s, 1))
+			clock.NowForce(now)
+		})
+	}
+}
+
+func Test_Record_JSONRoundTrips(t *testing.T) {
+	clock.NowForce(time.Unix(1500000000, 0))
+	defer clock.NowReset()
+
+	caa := NewTimeoutRecord()
+	caa.Issue()
+	caa.RevokeBecause(1500000000, ReasonUserLogout, "user:1")
+	caa.LockBecause(ReasonAdmin, "")
+
+	b, err := json.Marshal(caa)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "timeout",
+		"value": -1500000000,
+		"last_revoke": {"at": "2017-07-14T02:40:00Z", "reason": "user-logout", "actor": "user:1"},
+		"last_lock": {"at": "2017-07-14T02:40:00Z", "reason": "admin"}
+	}`, string(b))
+
+	v, err := caa.Value()
+	assert.NoError(t, err)
+
+	scanned := &Record{}
+	assert.NoError(t, scanned.Scan(v))
+	assert.Equal(t, caa, scanned)
+}
+
+func Test_Record_ScanNullIsNeverIssued(t *testing.T) {
+	caa := NewTimeoutRecord()
+	caa.Issue()
+	caa.RevokeBecause(1, ReasonUserLogout, "user:1")
+	caa.LockBecause(ReasonAdmin, "")
+
+	assert.NoError(t, caa.Scan(nil))
+	assert.Equal(t, NewTimeoutRecord(), caa)
+	assert.Equal(t, KindTimeout, caa.Kind())
+	assert.False(t, caa.HasIssued())
+}
+
+func Test_Record_JSONOmitsChangesNeverRecorded(t *testing.T) {
+	caa := NewCounterRecord()
+	caa.Issue()
+
+	b, err := json.Marshal(caa)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type": "counter", "value": 1}`, string(b))
+}
+
+func Test_Record_UnmarshalRejectsUnknownTypesAndReasons(t *testing.T) {
+	tests := []string{
+		`{"type": "sliding", "value": 1}`,
+		`{"value": 1}`,
+		`{"type": "counter", "value": 1, "last_lock": {"reason": "bored"}}`,
+	}
+
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			caa := NewCounterRecord()
+			err := json.Unmarshal([]byte(test), caa)
+			assert.True(t, errors.Is(err, ErrMalformedRecord), "%v", err)
+			assert.Equal(t, NewCounterRecord(), caa)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package compandauth
+
+import "errors"
+
+// ErrReuseDetected is returned when a refresh session CAA that has already
+// been rotated is presented again, indicating it may have been stolen.
+var ErrReuseDetected = errors.New("compandauth: refresh session reused")
+
+// ReuseAction is what a RefreshFamily does when reuse is detected.
+type ReuseAction int
+
+const (
+	// Revoke every session of the family, the legitimate user must log in
+	// again.
+	ReuseRevoke ReuseAction = iota
+	// Lock the family until unlocked (e.g. after review), at which point
+	// the latest session is valid again.
+	ReuseLock
+)
+
+// RefreshFamily is a Counter issuing a chain of refresh sessions, where only
+// the latest is valid. Each Refresh rotates the chain, and presenting a
+// session of the chain that has already been rotated means two parties hold
+// it, so the family is revoked or locked (see ReuseAction) cutting off both.
+//
+// Sessions invalidated by Revoke or by Issue starting a new family are
+// reported as ErrRevoked rather than as reuse. It is stored in a pair of
+// int64s: the Counter and the first session CAA of the current family.
+type RefreshFamily struct {
+	Counter Counter
+	Start   int64
+	OnReuse ReuseAction
+}
+
+func NewRefreshFamily(onReuse ReuseAction) *RefreshFamily {
+	return &RefreshFamily{OnReuse: onReuse}
+}
+
+// Locks CAA to prevent validation of session CAA's.
+func (caa *RefreshFamily) Lock() {
+	caa.Counter.Lock()
+}
+
+// Unlocks CAA to allow validation of session CAA's.
+func (caa *RefreshFamily) Unlock() {
+	caa.Counter.Unlock()
+}
+
+func (caa *RefreshFamily) IsLocked() bool {
+	return caa.Counter.IsLocked()
+}
+
+// Indicates if s is the latest session of the family. delta is ignored.
+func (caa *RefreshFamily) IsValid(s SessionCAA, delta int64) bool {
+	return caa.Validate(s, delta) == nil
+}
+
+// Validate behaves as IsValid but returns the reason a session CAA is
+// considered invalid: ErrReuseDetected if s was rotated, ErrRevoked if s was
+// revoked or belongs to a previous family, or see Counter.Validate. Unlike
+// Refresh no action is taken on reuse. delta is ignored.
+func (caa *RefreshFamily) Validate(s SessionCAA, delta int64) error {
+	if err := caa.Counter.ValidateStrict(s, int64(caa.Counter.abs())); err != nil {
+		return err
+	}
+
+	sessionCAA := abs(int64(s))
+	switch {
+	case sessionCAA < caa.Start:
+		return ErrRevoked
+	case sessionCAA+1 < int64(caa.Counter.abs()):
+		return ErrReuseDetected
+	}
+
+	return nil
+}
+
+// Validates s and if it is the latest session of the family issues the next,
+// invalidating s. If s has already been rotated the family is revoked or
+// locked according to OnReuse and ErrReuseDetected returned. See Validate
+// for the other possible errors.
+func (caa *RefreshFamily) Refresh(s SessionCAA) (SessionCAA, error) {
+	err := caa.Validate(s, 1)
+	switch err {
+	case nil:
+		return caa.Counter.Issue(), nil
+	case ErrReuseDetected:
+		if caa.OnReuse == ReuseLock {
+			caa.Lock()
+		} else {
+			caa.Revoke(1)
+		}
+	}
+
+	return 0, err
+}
+
+// Rotate implements Rotator, see Refresh. delta is ignored.
+func (caa *RefreshFamily) Rotate(old SessionCAA, delta int64) (SessionCAA, error) {
+	return caa.Refresh(old)
+}
+
+// Revokes every session of the family, n is ignored. If the CAA has never
+// issued it has no effect.
+func (caa *RefreshFamily) Revoke(n int64) {
+	if !caa.HasIssued() {
+		return
+	}
+
+	caa.Counter.Revoke(1)
+	caa.Start = int64(caa.Counter.abs())
+}
+
+// Starts a new family, e.g. on login, returning its first session CAA. All
+// sessions of the previous family are revoked.
+func (caa *RefreshFamily) Issue() SessionCAA {
+	caa.Start = int64(caa.Counter.abs())
+
+	return caa.Counter.Issue()
+}
+
+func (caa *RefreshFamily) HasIssued() bool {
+	return caa.Counter.HasIssued()
+}
+
+func (caa *RefreshFamily) raw() State {
+	return State{Value: int64(caa.Counter), Aux: caa.Start, Known: true}
+}
+
+var _ = CAA(NewRefreshFamily(ReuseRevoke))
+var _ = Rotator(NewRefreshFamily(ReuseRevoke))
@@ -0,0 +1,135 @@
+package compandauth
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_RefreshFamily_RotatesOnRefresh(t *testing.T) {
+	caa := NewRefreshFamily(ReuseRevoke)
+	s := caa.Issue()
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, caa.Validate(s, 1))
+
+		next, err := caa.Refresh(s)
+		assert.NoError(t, err)
+		assert.Equal(t, s+1, next)
+		s = next
+	}
+
+	assert.True(t, caa.IsValid(s, 1))
+}
+
+func Test_RefreshFamily_AttackerReplayAfterLegitimateRefreshRevokesFamily(t *testing.T) {
+	caa := NewRefreshFamily(ReuseRevoke)
+	stolen := caa.Issue()
+	legitimate, err := caa.Refresh(stolen)
+	assert.NoError(t, err)
+
+	_, err = caa.Refresh(stolen)
+	assert.Equal(t, ErrReuseDetected, err)
+
+	_, err = caa.Refresh(legitimate)
+	assert.Equal(t, ErrRevoked, err)
+	_, err = caa.Refresh(stolen)
+	assert.Equal(t, ErrRevoked, err)
+}
+
+func Test_RefreshFamily_LegitimateReplayAfterAttackerRefreshRevokesFamily(t *testing.T) {
+	caa := NewRefreshFamily(ReuseRevoke)
+	legitimate := caa.Issue()
+	attacker, err := caa.Refresh(legitimate)
+	assert.NoError(t, err)
+
+	_, err = caa.Refresh(legitimate)
+	assert.Equal(t, ErrReuseDetected, err)
+
+	_, err = caa.Refresh(attacker)
+	assert.Equal(t, ErrRevoked, err)
+
+	// Logging in again starts a new family
+	s := caa.Issue()
+	_, err = caa.Refresh(s)
+	assert.NoError(t, err)
+}
+
+func Test_RefreshFamily_ReuseLocksFamilyWithReuseLock(t *testing.T) {
+	caa := NewRefreshFamily(ReuseLock)
+	stolen := caa.Issue()
+	legitimate, _ := caa.Refresh(stolen)
+
+	_, err := caa.Refresh(stolen)
+	assert.Equal(t, ErrReuseDetected, err)
+	assert.True(t, caa.IsLocked())
+	_, err = caa.Refresh(legitimate)
+	assert.Equal(t, ErrLocked, err)
+
+	caa.Unlock()
+	_, err = caa.Refresh(legitimate)
+	assert.NoError(t, err)
+}
+
+func Test_RefreshFamily_RevokedSessionsAreNotReuse(t *testing.T) {
+	caa := NewRefreshFamily(ReuseLock)
+	first := caa.Issue()
+	second, _ := caa.Refresh(first)
+
+	// Logout
+	caa.Revoke(1)
+	assert.Equal(t, ErrRevoked, caa.Validate(second, 1))
+	_, err := caa.Refresh(first)
+	assert.Equal(t, ErrRevoked, err)
+
+	// Login again
+	third := caa.Issue()
+	fourth, _ := caa.Refresh(third)
+	_, err = caa.Refresh(second)
+	assert.Equal(t, ErrRevoked, err)
+
+	// New family starting on another login
+	caa.Issue()
+	_, err = caa.Refresh(fourth)
+	assert.Equal(t, ErrRevoked, err)
+	assert.False(t, caa.IsLocked())
+}
+
+func Test_RefreshFamily_InvalidSessionsTakeNoAction(t *testing.T) {
+	caa := NewRefreshFamily(ReuseRevoke)
+	_, err := caa.Refresh(0)
+	assert.Equal(t, ErrNeverIssued, err)
+
+	s := caa.Issue()
+	_, err = caa.Refresh(s + 10)
+	assert.Equal(t, ErrFutureSession, err)
+
+	next, _ := caa.Refresh(s)
+	assert.Equal(t, ErrReuseDetected, caa.Validate(s, 1))
+	assert.NoError(t, caa.Validate(next, 1))
+}
+
+func Test_RefreshFamily_ConcurrentReplaysOnlyRefreshOnce(t *testing.T) {
+	caa := NewThreadSafe(NewRefreshFamily(ReuseRevoke))
+	stolen := caa.Issue()
+
+	var mu sync.Mutex
+	results := map[error]int{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := caa.Rotate(stolen, 1)
+
+			mu.Lock()
+			results[err]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[error]int{nil: 1, ErrReuseDetected: 1, ErrRevoked: 98}, results)
+}
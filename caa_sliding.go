@@ -0,0 +1,184 @@
+package compandauth
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/endiangroup/compandauth/clock"
+)
+
+// Sliding is a Timeout with an idle timeout: sessions are valid whilst the
+// entity has been active within the last idleSecs, up to a hard deadline of
+// AbsoluteSecs after they were issued. Activity is recorded by Issue and by
+// every successful Touch, so Touch must be used when validating sessions and
+// the Sliding persisted afterwards (much as after Issue).
+//
+// Activity is tracked per entity rather than per session, any session in use
+// keeps all of the entity's sessions alive, which is why every path
+// validating a session (including Validate and Rotate) enforces the hard
+// deadline. It is stored in a pair of int64s: the Timeout and the unix
+// timestamp in seconds of the last activity, both of which must be persisted
+// (see Value). AbsoluteSecs and Leeway are configuration rather than state,
+// so needn't be persisted. Leeway is the number of seconds clocks across
+// servers may be out of sync by, see Timeout.ValidateAtWithLeeway. With the
+// default Leeway of 0 sessions issued after now are accepted, as
+// Timeout.Validate does.
+type Sliding struct {
+	Timeout      Timeout
+	LastActive   int64
+	AbsoluteSecs int64 `json:"-"`
+	Leeway       int64 `json:"-"`
+}
+
+// NewSliding returns a Sliding whose sessions expire absolute after they were
+// issued however active the entity is, 0 for no hard deadline.
+func NewSliding(absolute time.Duration) *Sliding {
+	return &Sliding{AbsoluteSecs: ToSeconds(absolute)}
+}
+
+// Locks CAA to prevent validation of session CAA's.
+func (caa *Sliding) Lock() {
+	caa.Timeout.Lock()
+}
+
+// Unlocks CAA to allow validation of session CAA's.
+func (caa *Sliding) Unlock() {
+	caa.Timeout.Unlock()
+}
+
+func (caa *Sliding) IsLocked() bool {
+	return caa.Timeout.IsLocked()
+}
+
+// Indicates if a session CAA is valid given the entity has been active in the
+// last idleSecs and the session was issued within AbsoluteSecs, without
+// recording activity. Use Touch to validate incoming sessions.
+func (caa *Sliding) IsValid(s SessionCAA, idleSecs int64) bool {
+	return caa.Validate(s, idleSecs) == nil
+}
+
+// Validate behaves as IsValid but returns the reason a session CAA is
+// considered invalid, see TouchAt.
+func (caa *Sliding) Validate(s SessionCAA, idleSecs int64) error {
+	return caa.validateAt(s, idleSecs, clock.Now())
+}
+
+// Validates a session CAA, see TouchAt.
+func (caa *Sliding) Touch(s SessionCAA, idleSecs int64) error {
+	return caa.TouchAt(s, idleSecs, clock.Now())
+}
+
+// Validates a session CAA at the given time, recording the entity as active
+// if it is valid. A session is valid if the entity was last active no more
+// than idleSecs ago and the session was issued no more than AbsoluteSecs ago,
+// otherwise ErrExpired is returned. See Timeout.Validate for the other
+// possible errors.
+func (caa *Sliding) TouchAt(s SessionCAA, idleSecs int64, at time.Time) error {
+	if err := caa.validateAt(s, idleSecs, at); err != nil {
+		return err
+	}
+
+	caa.LastActive = at.Unix()
+	return nil
+}
+
+// Issues the next CAA value to use in a distributed session, see
+// Timeout.Issue, and records the entity as active.
+func (caa *Sliding) Issue() SessionCAA {
+	return caa.IssueAt(clock.Now())
+}
+
+// Issue at the given time rather than clock.Now, see Issue.
+func (caa *Sliding) IssueAt(at time.Time) SessionCAA {
+	caa.LastActive = at.Unix()
+
+	return caa.Timeout.IssueAt(at)
+}
+
+// Validates old as Touch does and if valid issues its replacement, see
+// Timeout.Rotate. Old must be within its hard deadline, so a session can't
+// be rotated to outlive it.
+func (caa *Sliding) Rotate(old SessionCAA, idleSecs int64) (SessionCAA, error) {
+	return caa.RotateAt(old, idleSecs, clock.Now())
+}
+
+// Rotate at the given time rather than clock.Now, see Rotate.
+func (caa *Sliding) RotateAt(old SessionCAA, idleSecs int64, at time.Time) (SessionCAA, error) {
+	if err := caa.validateAt(old, idleSecs, at); err != nil {
+		return 0, err
+	}
+
+	return caa.IssueAt(at), nil
+}
+
+// Invalidates all sessions issued before expiryTimestamp, see
+// Timeout.Revoke.
+func (caa *Sliding) Revoke(expiryTimestamp int64) {
+	caa.Timeout.Revoke(expiryTimestamp)
+}
+
+// Indicates if the CAA has issued at least once, regardless if it has been
+// locked.
+func (caa *Sliding) HasIssued() bool {
+	return caa.Timeout.HasIssued()
+}
+
+// Value implements driver.Valuer, storing both the Timeout and LastActive as
+// JSON. As it isn't a single int64 it can't be used with store.Mutate, which
+// returns an error rather than dropping LastActive.
+func (caa *Sliding) Value() (driver.Value, error) {
+	return json.Marshal(caa)
+}
+
+// Scan implements sql.Scanner, a NULL is considered a Sliding that has never
+// issued. AbsoluteSecs and Leeway are kept.
+func (caa *Sliding) Scan(src interface{}) error {
+	var b []byte
+	switch v := src.(type) {
+	case nil:
+		caa.Timeout, caa.LastActive = 0, 0
+		return nil
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return fmt.Errorf("compandauth: scanning Sliding: unsupported type %T", src)
+	}
+
+	var scanned Sliding
+	if err := json.Unmarshal(b, &scanned); err != nil {
+		return fmt.Errorf("compandauth: scanning Sliding: %w", err)
+	}
+
+	caa.Timeout, caa.LastActive = scanned.Timeout, scanned.LastActive
+	return nil
+}
+
+func (caa *Sliding) validateAt(s SessionCAA, idleSecs int64, at time.Time) error {
+	// The session can't outlive AbsoluteSecs so is validated as if it were
+	// a Timeout with that duration
+	durationSecs := abs(caa.AbsoluteSecs)
+	if durationSecs == 0 {
+		durationSecs = at.Unix() - abs(int64(s))
+	}
+
+	if err := caa.Timeout.validateAt(s, durationSecs, caa.Leeway, at, caa.Leeway != 0); err != nil {
+		return err
+	}
+
+	if caa.LastActive+abs(idleSecs) < at.Unix() {
+		return ErrExpired
+	}
+
+	return nil
+}
+
+func (caa *Sliding) raw() State {
+	return State{Value: int64(caa.Timeout), Aux: caa.LastActive, Known: true}
+}
+
+var _ = CAA(NewSliding(0))
+var _ = Rotator(NewSliding(0))
@@ -0,0 +1,156 @@
+package compandauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/endiangroup/compandauth/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Sliding_StaysValidWhilstActiveWithinIdleTimeout(t *testing.T) {
+	fake := clock.NewFake(time.Unix(1500000000, 0))
+	caa := NewSliding(0)
+	s := caa.IssueAt(fake.Now())
+
+	for i := 0; i < 10; i++ {
+		fake.Advance(25 * time.Second)
+		assert.NoError(t, caa.TouchAt(s, 30, fake.Now()))
+	}
+
+	fake.Advance(31 * time.Second)
+	assert.Equal(t, ErrExpired, caa.TouchAt(s, 30, fake.Now()))
+}
+
+func Test_Sliding_ExpiresAtAbsoluteDeadlineRegardlessOfActivity(t *testing.T) {
+	fake := clock.NewFake(time.Unix(1500000000, 0))
+	caa := NewSliding(100 * time.Second)
+	s := caa.IssueAt(fake.Now())
+
+	for i := 0; i < 4; i++ {
+		fake.Advance(25 * time.Second)
+		assert.NoError(t, caa.TouchAt(s, 30, fake.Now()))
+	}
+
+	fake.Advance(25 * time.Second)
+	assert.Equal(t, ErrExpired, caa.TouchAt(s, 30, fake.Now()))
+}
+
+func Test_Sliding_ValidateEnforcesAbsoluteDeadline(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	clock.NowForce(start)
+	defer clock.NowReset()
+
+	caa := NewSliding(100 * time.Second)
+	stolen := caa.Issue()
+
+	// The entity stays active on a newer session
+	for i := 1; i <= 5; i++ {
+		clock.NowForce(start.Add(time.Duration(i) * 25 * time.Second))
+		assert.NoError(t, caa.Touch(caa.Issue(), 30))
+	}
+
+	assert.Equal(t, ErrExpired, caa.Validate(stolen, 30))
+	_, err := NewThreadSafe(caa).IssueIfValid(stolen, 30)
+	assert.Equal(t, ErrExpired, err)
+	_, err = NewThreadSafe(caa).Rotate(stolen, 30)
+	assert.Equal(t, ErrExpired, err)
+}
+
+func Test_Sliding_FailedTouchDoesNotRecordActivity(t *testing.T) {
+	fake := clock.NewFake(time.Unix(1500000000, 0))
+	caa := NewSliding(0)
+	s := caa.IssueAt(fake.Now())
+	lastActive := caa.LastActive
+
+	fake.Advance(10 * time.Second)
+	caa.Leeway = 5
+	assert.Equal(t, ErrFutureSession, caa.TouchAt(s+60, 30, fake.Now()))
+	assert.Equal(t, lastActive, caa.LastActive)
+
+	caa.Lock()
+	assert.Equal(t, ErrLocked, caa.TouchAt(s, 30, fake.Now()))
+	assert.Equal(t, lastActive, caa.LastActive)
+}
+
+func Test_Sliding_RevokeInvalidatesEarlierSessions(t *testing.T) {
+	fake := clock.NewFake(time.Unix(1500000000, 0))
+	caa := NewSliding(0)
+	old := caa.IssueAt(fake.Now())
+
+	fake.Advance(10 * time.Second)
+	caa.Revoke(fake.Now().Unix())
+	new := caa.IssueAt(fake.Now())
+
+	assert.Equal(t, ErrRevoked, caa.TouchAt(old, 30, fake.Now()))
+	assert.NoError(t, caa.TouchAt(new, 30, fake.Now()))
+}
+
+func Test_Sliding_ValidateDoesNotRecordActivity(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	clock.NowForce(start)
+	defer clock.NowReset()
+
+	caa := NewSliding(0)
+	s := caa.Issue()
+
+	clock.NowForce(start.Add(20 * time.Second))
+	assert.True(t, caa.IsValid(s, 30))
+
+	clock.NowForce(start.Add(40 * time.Second))
+	assert.Equal(t, ErrExpired, caa.Validate(s, 30))
+	assert.Equal(t, start.Unix(), caa.LastActive)
+}
+
+func Test_Sliding_PersistsBothTimeoutAndLastActive(t *testing.T) {
+	fake := clock.NewFake(time.Unix(1500000000, 0))
+	caa := NewSliding(time.Minute)
+	s := caa.IssueAt(fake.Now())
+	fake.Advance(20 * time.Second)
+	assert.NoError(t, caa.TouchAt(s, 30, fake.Now()))
+	caa.Lock()
+
+	v, err := caa.Value()
+	assert.NoError(t, err)
+
+	scanned := NewSliding(time.Minute)
+	assert.NoError(t, scanned.Scan(v))
+	assert.Equal(t, caa, scanned)
+	assert.Equal(t, int64(1500000020), scanned.LastActive)
+
+	assert.NoError(t, scanned.Scan(nil))
+	assert.Equal(t, NewSliding(time.Minute), scanned)
+	assert.Error(t, scanned.Scan(int64(1500000000)))
+}
+
+func Test_Sliding_RotateEnforcesAbsoluteDeadline(t *testing.T) {
+	fake := clock.NewFake(time.Unix(1500000000, 0))
+	caa := NewSliding(time.Minute)
+	s := caa.IssueAt(fake.Now())
+
+	fake.Advance(20 * time.Second)
+	next, err := caa.RotateAt(s, 30, fake.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, SessionCAA(1500000020), next)
+
+	fake.Advance(20 * time.Second)
+	assert.NoError(t, caa.TouchAt(s, 30, fake.Now()))
+	fake.Advance(21 * time.Second)
+	_, err = caa.RotateAt(s, 30, fake.Now())
+	assert.Equal(t, ErrExpired, err)
+	assert.NoError(t, caa.TouchAt(next, 30, fake.Now()))
+}
+
+func Test_Sliding_ToleratesSkewUnlessLeewayIsSet(t *testing.T) {
+	at := time.Unix(1500000000, 0)
+	caa := NewSliding(time.Minute)
+	s := caa.IssueAt(at.Add(2 * time.Second))
+
+	assert.NoError(t, caa.TouchAt(s, 30, at))
+
+	caa.Leeway = 2
+	assert.NoError(t, caa.TouchAt(s, 30, at))
+
+	caa.Leeway = 1
+	assert.Equal(t, ErrFutureSession, caa.TouchAt(s, 30, at))
+}
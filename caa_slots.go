@@ -0,0 +1,305 @@
+package compandauth
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// DefaultSlot is the slot Slots.Issue issues into.
+const DefaultSlot = ""
+
+// ErrMalformedSlots is returned when unmarshalling invalid Slots.
+var ErrMalformedSlots = errors.New("compandauth: malformed slots")
+
+// Slot is a named session, e.g. a device.
+type Slot struct {
+	Name    string
+	Session SessionCAA
+}
+
+// Slots is a Counter that tracks the last session issued to each of a bounded
+// set of named slots (e.g. "iPhone", "Laptop"). Issuing to a slot invalidates
+// the slot's previous session, so logging in again on the same device
+// doesn't push other devices out. Issuing to a new slot once MaxSlots are in
+// use evicts the slot with the oldest session.
+//
+// When validating, delta is the number of slots with the most recent
+// sessions to consider valid.
+//
+// The slots must be persisted along with the Counter, see MarshalBinary and
+// MarshalJSON. As its state can't be represented as a State, an Observed
+// Slots emits unknown states, and IssueSlot and RevokeSlot aren't observed
+// at all as they aren't part of CAA.
+type Slots struct {
+	Counter  Counter
+	MaxSlots int
+	// Ordered oldest session first
+	slots []Slot
+}
+
+func NewSlots(maxSlots int) *Slots {
+	return &Slots{MaxSlots: maxSlots}
+}
+
+// Locks CAA to prevent validation of session CAA's.
+func (caa *Slots) Lock() {
+	caa.Counter.Lock()
+}
+
+// Unlocks CAA to allow validation of session CAA's.
+func (caa *Slots) Unlock() {
+	caa.Counter.Unlock()
+}
+
+func (caa *Slots) IsLocked() bool {
+	return caa.Counter.IsLocked()
+}
+
+// Indicates if s is the current session of one of the delta slots with the
+// most recent sessions.
+func (caa *Slots) IsValid(s SessionCAA, delta int64) bool {
+	return caa.Validate(s, delta) == nil
+}
+
+// Validate behaves as IsValid but returns the reason a session CAA is
+// considered invalid, see Counter.Validate.
+func (caa *Slots) Validate(s SessionCAA, delta int64) error {
+	// Every session the Counter has issued is a candidate, the slots decide
+	// which are still valid
+	if err := caa.Counter.ValidateStrict(s, int64(caa.Counter.abs())); err != nil {
+		return err
+	}
+
+	s = SessionCAA(abs(int64(s)))
+	delta = abs(delta)
+	for i := len(caa.slots) - 1; i >= 0 && delta > 0; i-- {
+		if caa.slots[i].Session == s {
+			return nil
+		}
+		delta--
+	}
+
+	return ErrRevoked
+}
+
+// Invalidates the slots with the oldest n sessions.
+func (caa *Slots) Revoke(n int64) {
+	n = abs(n)
+	if n > int64(len(caa.slots)) {
+		n = int64(len(caa.slots))
+	}
+
+	caa.slots = append(caa.slots[:0], caa.slots[n:]...)
+}
+
+// Issues the next session CAA into DefaultSlot.
+func (caa *Slots) Issue() SessionCAA {
+	return caa.IssueSlot(DefaultSlot)
+}
+
+// Issues the next session CAA into the named slot, replacing its previous
+// session.
+func (caa *Slots) IssueSlot(name string) SessionCAA {
+	caa.RevokeSlot(name)
+
+	if caa.MaxSlots > 0 && len(caa.slots) >= caa.MaxSlots {
+		caa.Revoke(int64(len(caa.slots) - caa.MaxSlots + 1))
+	}
+
+	s := caa.Counter.Issue()
+	caa.slots = append(caa.slots, Slot{Name: name, Session: s})
+
+	return s
+}
+
+// Invalidates the named slot's session, reporting if there was one.
+func (caa *Slots) RevokeSlot(name string) bool {
+	for i, slot := range caa.slots {
+		if slot.Name == name {
+			caa.slots = append(caa.slots[:i], caa.slots[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the slots in use, most recently issued first.
+func (caa *Slots) ListSlots() []Slot {
+	slots := make([]Slot, len(caa.slots))
+	for i, slot := range caa.slots {
+		slots[len(slots)-1-i] = slot
+	}
+
+	return slots
+}
+
+// Indicates if the CAA has issued at least once, regardless if it has been
+// locked.
+func (caa *Slots) HasIssued() bool {
+	return caa.Counter.HasIssued()
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler with a compact varint
+// encoding of the Counter, MaxSlots and each slot.
+func (caa *Slots) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 3*binary.MaxVarintLen64+len(caa.slots)*(binary.MaxVarintLen64+8))
+	b = appendVarint(b, int64(caa.Counter))
+	b = appendVarint(b, int64(caa.MaxSlots))
+	b = appendVarint(b, int64(len(caa.slots)))
+
+	for _, slot := range caa.slots {
+		b = appendVarint(b, int64(len(slot.Name)))
+		b = append(b, slot.Name...)
+		b = appendVarint(b, int64(slot.Session))
+	}
+
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (caa *Slots) UnmarshalBinary(b []byte) error {
+	r := &varintReader{b: b}
+	counter := r.next()
+	maxSlots := r.next()
+	n := r.next()
+
+	if n < 0 || n > int64(len(b)) {
+		return ErrMalformedSlots
+	}
+
+	slots := make([]Slot, 0, n)
+	for i := int64(0); i < n && r.err == nil; i++ {
+		name := r.bytes(r.next())
+		session := r.next()
+		slots = append(slots, Slot{Name: string(name), Session: SessionCAA(session)})
+	}
+
+	if r.err != nil || len(r.b) != 0 {
+		return ErrMalformedSlots
+	}
+
+	ordered := sort.SliceIsSorted(slots, func(i, j int) bool {
+		return slots[i].Session < slots[j].Session
+	})
+	if !ordered {
+		return ErrMalformedSlots
+	}
+
+	caa.Counter = Counter(counter)
+	caa.MaxSlots = int(maxSlots)
+	caa.slots = slots
+
+	return nil
+}
+
+type slotsJSON struct {
+	Counter  Counter
+	MaxSlots int
+	Slots    []Slot
+}
+
+// MarshalJSON implements json.Marshaler, including the slots ordered oldest
+// session first.
+func (caa *Slots) MarshalJSON() ([]byte, error) {
+	return json.Marshal(slotsJSON{Counter: caa.Counter, MaxSlots: caa.MaxSlots, Slots: caa.slots})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, returning ErrMalformedSlots if
+// the slots aren't ordered oldest session first.
+func (caa *Slots) UnmarshalJSON(b []byte) error {
+	var sj slotsJSON
+	if err := json.Unmarshal(b, &sj); err != nil {
+		return err
+	}
+
+	ordered := sort.SliceIsSorted(sj.Slots, func(i, j int) bool {
+		return sj.Slots[i].Session < sj.Slots[j].Session
+	})
+	if !ordered {
+		return ErrMalformedSlots
+	}
+
+	caa.Counter = sj.Counter
+	caa.MaxSlots = sj.MaxSlots
+	caa.slots = sj.Slots
+
+	return nil
+}
+
+// Value implements driver.Valuer, storing the Slots as JSON. As it isn't a
+// single int64 it can't be used with store.Mutate.
+func (caa *Slots) Value() (driver.Value, error) {
+	return caa.MarshalJSON()
+}
+
+// Scan implements sql.Scanner, a NULL is considered Slots that have never
+// issued. MaxSlots is kept.
+func (caa *Slots) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		caa.Counter, caa.slots = 0, nil
+		return nil
+	case []byte:
+		return caa.UnmarshalJSON(v)
+	case string:
+		return caa.UnmarshalJSON([]byte(v))
+	}
+
+	return fmt.Errorf("compandauth: scanning Slots: unsupported type %T", src)
+}
+
+func appendVarint(b []byte, i int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(buf[:], i)
+
+	return append(b, buf[:n]...)
+}
+
+// Reads consecutive varints, recording the first error.
+type varintReader struct {
+	b   []byte
+	err error
+}
+
+func (r *varintReader) next() int64 {
+	if r.err != nil {
+		return 0
+	}
+
+	i, n := binary.Varint(r.b)
+	if n <= 0 {
+		r.err = ErrMalformedSlots
+		return 0
+	}
+	r.b = r.b[n:]
+
+	return i
+}
+
+func (r *varintReader) bytes(n int64) []byte {
+	if r.err != nil {
+		return nil
+	}
+
+	if n < 0 || n > int64(len(r.b)) {
+		r.err = ErrMalformedSlots
+		return nil
+	}
+
+	b := r.b[:n]
+	r.b = r.b[n:]
+
+	return b
+}
+
+// The slots can't be represented, so the state is unknown.
+func (caa *Slots) raw() State {
+	return State{}
+}
+
+var _ = CAA(NewSlots(1))
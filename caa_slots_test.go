@@ -0,0 +1,166 @@
+package compandauth
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Slots_ReissuingASlotReplacesItsSession(t *testing.T) {
+	caa := NewSlots(3)
+	iphone := caa.IssueSlot("iPhone")
+	laptop := caa.IssueSlot("Laptop")
+
+	for i := 0; i < 10; i++ {
+		iphone = caa.IssueSlot("iPhone")
+	}
+
+	assert.NoError(t, caa.Validate(iphone, 2))
+	assert.NoError(t, caa.Validate(laptop, 2))
+	assert.Equal(t, ErrRevoked, caa.Validate(iphone-1, 2))
+	assert.Equal(t, []Slot{{Name: "iPhone", Session: iphone}, {Name: "Laptop", Session: laptop}}, caa.ListSlots())
+}
+
+func Test_Slots_DeltaLimitsTheNumberOfValidSlots(t *testing.T) {
+	caa := NewSlots(3)
+	iphone := caa.IssueSlot("iPhone")
+	laptop := caa.IssueSlot("Laptop")
+	work := caa.IssueSlot("Work PC")
+
+	assert.Equal(t, ErrRevoked, caa.Validate(iphone, 2))
+	assert.NoError(t, caa.Validate(laptop, 2))
+	assert.NoError(t, caa.Validate(work, 2))
+	assert.NoError(t, caa.Validate(iphone, 3))
+}
+
+func Test_Slots_IssuingBeyondMaxSlotsEvictsTheOldest(t *testing.T) {
+	caa := NewSlots(2)
+	iphone := caa.IssueSlot("iPhone")
+	laptop := caa.IssueSlot("Laptop")
+	work := caa.IssueSlot("Work PC")
+
+	assert.Equal(t, ErrRevoked, caa.Validate(iphone, 3))
+	assert.NoError(t, caa.Validate(laptop, 3))
+	assert.NoError(t, caa.Validate(work, 3))
+	assert.Len(t, caa.ListSlots(), 2)
+}
+
+func Test_Slots_RevokeSlotOnlyRevokesThatSlot(t *testing.T) {
+	caa := NewSlots(3)
+	iphone := caa.IssueSlot("iPhone")
+	laptop := caa.IssueSlot("Laptop")
+
+	assert.True(t, caa.RevokeSlot("iPhone"))
+	assert.False(t, caa.RevokeSlot("iPhone"))
+
+	assert.Equal(t, ErrRevoked, caa.Validate(iphone, 3))
+	assert.NoError(t, caa.Validate(laptop, 3))
+}
+
+func Test_Slots_RevokeRevokesTheOldestSlots(t *testing.T) {
+	caa := NewSlots(3)
+	caa.IssueSlot("iPhone")
+	caa.IssueSlot("Laptop")
+	work := caa.IssueSlot("Work PC")
+
+	caa.Revoke(2)
+	assert.Equal(t, []Slot{{Name: "Work PC", Session: work}}, caa.ListSlots())
+
+	caa.Revoke(5)
+	assert.Empty(t, caa.ListSlots())
+	assert.True(t, caa.HasIssued())
+}
+
+func Test_Slots_ValidateRejectsLockedUnissuedAndFutureSessions(t *testing.T) {
+	caa := NewSlots(3)
+	assert.Equal(t, ErrNeverIssued, caa.Validate(0, 3))
+
+	s := caa.Issue()
+	assert.Equal(t, ErrFutureSession, caa.Validate(s+1, 3))
+
+	caa.Lock()
+	assert.True(t, caa.IsLocked())
+	assert.Equal(t, ErrLocked, caa.Validate(s, 3))
+
+	caa.Unlock()
+	assert.True(t, caa.IsValid(s, 3))
+	assert.Equal(t, []Slot{{Name: DefaultSlot, Session: s}}, caa.ListSlots())
+}
+
+func Test_Slots_BinaryRoundTrips(t *testing.T) {
+	caa := NewSlots(3)
+	caa.IssueSlot("iPhone")
+	caa.IssueSlot("Laptop")
+	caa.IssueSlot("iPhone")
+	caa.Lock()
+
+	b, err := caa.MarshalBinary()
+	assert.NoError(t, err)
+
+	decoded := &Slots{}
+	assert.NoError(t, decoded.UnmarshalBinary(b))
+	assert.Equal(t, caa, decoded)
+
+	empty, err := NewSlots(2).MarshalBinary()
+	assert.NoError(t, err)
+	assert.NoError(t, decoded.UnmarshalBinary(empty))
+	assert.Equal(t, 2, decoded.MaxSlots)
+	assert.Empty(t, decoded.ListSlots())
+}
+
+func Test_Slots_JSONRoundTrips(t *testing.T) {
+	caa := NewSlots(3)
+	caa.IssueSlot("iPhone")
+	caa.IssueSlot("Laptop")
+	caa.Lock()
+
+	b, err := json.Marshal(caa)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Counter":-2,"MaxSlots":3,"Slots":[{"Name":"iPhone","Session":0},{"Name":"Laptop","Session":1}]}`, string(b))
+
+	decoded := &Slots{}
+	assert.NoError(t, json.Unmarshal(b, decoded))
+	assert.Equal(t, caa, decoded)
+
+	assert.Equal(t, ErrMalformedSlots, decoded.UnmarshalJSON([]byte(`{"Counter":2,"Slots":[{"Session":1},{"Session":0}]}`)))
+}
+
+func Test_Slots_ScansValue(t *testing.T) {
+	caa := NewSlots(3)
+	caa.IssueSlot("iPhone")
+	caa.IssueSlot("Laptop")
+
+	v, err := caa.Value()
+	assert.NoError(t, err)
+
+	scanned := NewSlots(3)
+	assert.NoError(t, scanned.Scan(v))
+	assert.Equal(t, caa, scanned)
+
+	assert.NoError(t, scanned.Scan(nil))
+	assert.False(t, scanned.HasIssued())
+	assert.Empty(t, scanned.ListSlots())
+	assert.Equal(t, 3, scanned.MaxSlots)
+}
+
+func Test_Slots_UnmarshalBinaryRejectsMalformedInput(t *testing.T) {
+	caa := NewSlots(3)
+	caa.IssueSlot("iPhone")
+	caa.IssueSlot("Laptop")
+	b, _ := caa.MarshalBinary()
+
+	tests := map[string][]byte{
+		"empty":      {},
+		"truncated":  b[:len(b)-1],
+		"trailing":   append(append([]byte{}, b...), 0),
+		"huge count": {2, 6, 0xfe, 0xff, 0xff, 0xff, 0x0f},
+		"unordered":  {6, 6, 4, 2, 'a', 2, 2, 'b', 0},
+	}
+
+	for name, b := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, ErrMalformedSlots, (&Slots{}).UnmarshalBinary(b))
+		})
+	}
+}
@@ -2,8 +2,8 @@ package compandauth
 
 import "sync"
 
-func NewThreadSafe(caa CAA) ThreadSafe {
-	return ThreadSafe{
+func NewThreadSafe(caa CAA) *ThreadSafe {
+	return &ThreadSafe{
 		CAA: caa,
 	}
 }
@@ -13,7 +13,8 @@ func NewThreadSafe(caa CAA) ThreadSafe {
 // The routine which handles an incoming request fetches
 // an entity with a CAA attached, it then proceeds to
 // spin off go routines with that entity which might affect
-// the CAA
+// the CAA. All access to the wrapped CAA must go through
+// the ThreadSafe, and it must not be copied after first use.
 type ThreadSafe struct {
 	CAA
 	mu sync.RWMutex
@@ -21,19 +22,19 @@ type ThreadSafe struct {
 
 func (t *ThreadSafe) Lock() {
 	t.mu.Lock()
-	t.Lock()
+	t.CAA.Lock()
 	t.mu.Unlock()
 }
 
 func (t *ThreadSafe) Unlock() {
 	t.mu.Lock()
-	t.Unlock()
+	t.CAA.Unlock()
 	t.mu.Unlock()
 }
 
 func (t *ThreadSafe) IsLocked() bool {
 	t.mu.RLock()
-	isLocked := t.IsLocked()
+	isLocked := t.CAA.IsLocked()
 	t.mu.RUnlock()
 
 	return isLocked
@@ -41,21 +42,29 @@ func (t *ThreadSafe) IsLocked() bool {
 
 func (t *ThreadSafe) IsValid(s SessionCAA, n int64) bool {
 	t.mu.RLock()
-	isValid := t.IsValid(s, n)
+	isValid := t.CAA.IsValid(s, n)
 	t.mu.RUnlock()
 
 	return isValid
 }
 
+func (t *ThreadSafe) Validate(s SessionCAA, n int64) error {
+	t.mu.RLock()
+	err := t.CAA.Validate(s, n)
+	t.mu.RUnlock()
+
+	return err
+}
+
 func (t *ThreadSafe) Revoke(n int64) {
 	t.mu.Lock()
-	t.Revoke(n)
+	t.CAA.Revoke(n)
 	t.mu.Unlock()
 }
 
 func (t *ThreadSafe) Issue() SessionCAA {
 	t.mu.Lock()
-	sessionCaa := t.Issue()
+	sessionCaa := t.CAA.Issue()
 	t.mu.Unlock()
 
 	return sessionCaa
@@ -63,8 +72,65 @@ func (t *ThreadSafe) Issue() SessionCAA {
 
 func (t *ThreadSafe) HasIssued() bool {
 	t.mu.RLock()
-	hasIssued := t.HasIssued()
+	hasIssued := t.CAA.HasIssued()
 	t.mu.RUnlock()
 
 	return hasIssued
 }
+
+// Issues a new session CAA only if s is valid, with no other operation able
+// to lock or revoke in between. Returns the reason s is invalid otherwise,
+// see Validate.
+func (t *ThreadSafe) IssueIfValid(s SessionCAA, n int64) (SessionCAA, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.CAA.Validate(s, n); err != nil {
+		return 0, err
+	}
+
+	return t.CAA.Issue(), nil
+}
+
+// Rotates old using the wrapped CAA's Rotate if it is a Rotator, otherwise
+// as IssueIfValid, with no other operation able to lock, revoke or issue in
+// between.
+func (t *ThreadSafe) Rotate(old SessionCAA, n int64) (SessionCAA, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if rotator, ok := t.CAA.(Rotator); ok {
+		return rotator.Rotate(old, n)
+	}
+
+	if err := t.CAA.Validate(old, n); err != nil {
+		return 0, err
+	}
+
+	return t.CAA.Issue(), nil
+}
+
+// Validates s and if valid revokes with revokeN (see the wrapped CAA's
+// Revoke), with no other operation able to lock, revoke or issue in between.
+// E.g. to allow a session CAA to only be used once. Returns the reason s is
+// invalid otherwise, see Validate.
+func (t *ThreadSafe) ValidateAndRevoke(s SessionCAA, n, revokeN int64) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.CAA.Validate(s, n); err != nil {
+		return err
+	}
+
+	t.CAA.Revoke(revokeN)
+	return nil
+}
+
+func (t *ThreadSafe) raw() State {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return rawState(t.CAA)
+}
+
+var _ = CAA(NewThreadSafe(NewCounter()))
@@ -0,0 +1,184 @@
+package compandauth
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ThreadSafe_DelegatesToWrappedCAA(t *testing.T) {
+	caa := NewThreadSafe(NewCounter())
+	counter := NewCounter()
+
+	assert.False(t, caa.HasIssued())
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, counter.Issue(), caa.Issue())
+	}
+	assert.True(t, caa.HasIssued())
+
+	caa.Revoke(3)
+	counter.Revoke(3)
+	assert.Equal(t, counter, caa.CAA)
+
+	caa.Lock()
+	assert.True(t, caa.IsLocked())
+	assert.Equal(t, ErrLocked, caa.Validate(9, 5))
+
+	caa.Unlock()
+	assert.False(t, caa.IsLocked())
+	assert.True(t, caa.IsValid(9, 5))
+	assert.Equal(t, ErrRevoked, caa.Validate(0, 5))
+}
+
+func Test_ThreadSafe_IssueIfValid(t *testing.T) {
+	caa := NewThreadSafe(NewCounter())
+	s := caa.Issue()
+
+	next, err := caa.IssueIfValid(s, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, SessionCAA(1), next)
+
+	caa.Lock()
+	_, err = caa.IssueIfValid(next, 2)
+	assert.Equal(t, ErrLocked, err)
+	assert.Equal(t, setCounterCAA(-2), caa.CAA)
+}
+
+func Test_ThreadSafe_ValidateAndRevoke(t *testing.T) {
+	caa := NewThreadSafe(NewCounter())
+	s := caa.Issue()
+
+	assert.NoError(t, caa.ValidateAndRevoke(s, 1, 1))
+	assert.Equal(t, ErrRevoked, caa.ValidateAndRevoke(s, 1, 1))
+	assert.Equal(t, setCounterCAA(2), caa.CAA)
+}
+
+func Test_ThreadSafe_ConcurrentMutationsAreNotLost(t *testing.T) {
+	caa := NewThreadSafe(NewCounter())
+	caa.Issue()
+
+	// IssueIfValid fails whilst another goroutine has the CAA locked
+	var issuedIfValid int64
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(5)
+		go func() { defer wg.Done(); caa.Issue() }()
+		go func() { defer wg.Done(); caa.Revoke(2) }()
+		go func() { defer wg.Done(); caa.Lock(); caa.Unlock() }()
+		go func() { defer wg.Done(); caa.IsValid(1, 10); caa.IsLocked(); caa.HasIssued() }()
+		go func() {
+			defer wg.Done()
+			if _, err := caa.IssueIfValid(0, 1<<20); err == nil {
+				atomic.AddInt64(&issuedIfValid, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, setCounterCAA(1+100+200+issuedIfValid), caa.CAA)
+}
+
+func Test_ThreadSafe_ValidateAndRevokeAllowsSingleUseConcurrently(t *testing.T) {
+	caa := NewThreadSafe(NewCounter())
+	s := caa.Issue()
+
+	var used int32
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if caa.ValidateAndRevoke(s, 1, 1) == nil {
+				atomic.AddInt32(&used, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), used)
+}
+
+func Test_ThreadSafe_RotateUsesWrappedRotator(t *testing.T) {
+	caa := NewThreadSafe(NewCounter())
+	s := caa.Issue()
+
+	next, err := caa.Rotate(s, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, SessionCAA(1), next)
+
+	_, err = caa.Rotate(s, 1)
+	assert.Equal(t, ErrRevoked, err)
+}
+
+func Test_ThreadSafe_RotateFallsBackToIssueIfValid(t *testing.T) {
+	caa := NewThreadSafe(NewObserved(NewCounter()))
+	s := caa.Issue()
+
+	next, err := caa.Rotate(s, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, SessionCAA(1), next)
+	assert.NoError(t, caa.Validate(s, 2))
+}
+
+func Test_ThreadSafe_RotateAllowsOnlyOneRotationOfASessionConcurrently(t *testing.T) {
+	for _, delta := range []int64{1, 3} {
+		caa := NewThreadSafe(NewCounter())
+		s := caa.Issue()
+
+		var rotated int32
+		var wg sync.WaitGroup
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if next, err := caa.Rotate(s, delta); err == nil {
+					atomic.AddInt32(&rotated, 1)
+					assert.NoError(t, caa.Validate(next, delta))
+				}
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), rotated, "delta %d", delta)
+	}
+}
+
+func Test_ThreadSafe_ConcurrentRotationsIssueUniqueSessions(t *testing.T) {
+	caa := NewThreadSafe(NewCounter())
+	goroutines, rotationsPerGoroutine := 20, 100
+	// Large enough that no session is pushed out by the others' rotations
+	delta := int64(2 * goroutines * (rotationsPerGoroutine + 1))
+
+	var mu sync.Mutex
+	issued := map[SessionCAA]bool{}
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			s := caa.Issue()
+			sessions := []SessionCAA{s}
+			for j := 0; j < rotationsPerGoroutine; j++ {
+				next, err := caa.Rotate(s, delta)
+				if !assert.NoError(t, err) {
+					return
+				}
+				s = next
+				sessions = append(sessions, s)
+			}
+
+			mu.Lock()
+			for _, s := range sessions {
+				issued[s] = true
+			}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, issued, goroutines*(rotationsPerGoroutine+1))
+}
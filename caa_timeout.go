@@ -30,14 +30,70 @@ func (caa Timeout) IsLocked() bool {
 // retrieved from a session token (e.g. JWT). durationSecs represents
 // number of seconds you would like to consider a session valid for.
 func (caa Timeout) IsValid(s SessionCAA, durationSecs int64) bool {
+	return caa.Validate(s, durationSecs) == nil
+}
+
+// Validate behaves as IsValid but returns the reason a session CAA is
+// considered invalid, one of ErrLocked, ErrNeverIssued, ErrRevoked or
+// ErrExpired. Returns nil if the session CAA is valid. Sessions issued after
+// now are accepted, as the issuing server's clock may be ahead, use
+// ValidateWithLeeway to bound how far.
+func (caa Timeout) Validate(s SessionCAA, durationSecs int64) error {
+	return caa.ValidateAt(s, durationSecs, clock.Now())
+}
+
+// Indicates if a session CAA is considered valid at the given time, see
+// IsValid.
+func (caa Timeout) IsValidAt(s SessionCAA, durationSecs int64, at time.Time) bool {
+	return caa.ValidateAt(s, durationSecs, at) == nil
+}
+
+// Validate at the given time rather than clock.Now, see Validate.
+func (caa Timeout) ValidateAt(s SessionCAA, durationSecs int64, at time.Time) error {
+	return caa.validateAt(s, durationSecs, 0, at, false)
+}
+
+// Validate allowing for clocks across servers being up to leewaySecs out of
+// sync, see ValidateAtWithLeeway.
+func (caa Timeout) ValidateWithLeeway(s SessionCAA, durationSecs, leewaySecs int64) error {
+	return caa.ValidateAtWithLeeway(s, durationSecs, leewaySecs, clock.Now())
+}
+
+// Validate at the given time allowing for clocks across servers being up to
+// leewaySecs out of sync, in the same manner as JWT nbf/exp leeway:
+//
+// - sessions issued up to leewaySecs after at are accepted, any later are
+// rejected with ErrFutureSession
+// - sessions issued up to leewaySecs before the revocation timestamp are
+// accepted, so a revocation only fully applies leewaySecs after it was made
+// - sessions are accepted for up to leewaySecs after they expire
+//
+// Keep leewaySecs as small as your clock synchronisation allows.
+func (caa Timeout) ValidateAtWithLeeway(s SessionCAA, durationSecs, leewaySecs int64, at time.Time) error {
+	return caa.validateAt(s, durationSecs, leewaySecs, at, true)
+}
+
+func (caa Timeout) validateAt(s SessionCAA, durationSecs, leewaySecs int64, at time.Time, rejectFuture bool) error {
 	sessionTimestamp := abs(int64(s))
 	durationSecs = abs(durationSecs)
+	leewaySecs = abs(leewaySecs)
 	expiryTimestamp := int64(caa.abs())
+	now := at.Unix()
+
+	switch {
+	case caa.IsLocked():
+		return ErrLocked
+	case !caa.HasIssued():
+		return ErrNeverIssued
+	case rejectFuture && sessionTimestamp > now+leewaySecs:
+		return ErrFutureSession
+	case sessionTimestamp+leewaySecs < expiryTimestamp:
+		return ErrRevoked
+	case (sessionTimestamp + durationSecs + leewaySecs) < now:
+		return ErrExpired
+	}
 
-	return !caa.IsLocked() &&
-		caa.HasIssued() &&
-		sessionTimestamp >= expiryTimestamp &&
-		(sessionTimestamp+durationSecs) >= clock.Now().Unix()
+	return nil
 }
 
 // Utility function to convert time.Duration into int64 seconds
@@ -61,7 +117,12 @@ func (caa *Timeout) Revoke(expiryTimestamp int64) {
 // locked it will still return the next valid session CAA value. CAA is only
 // set on first issue.
 func (caa *Timeout) Issue() SessionCAA {
-	now := clock.Now().Unix()
+	return caa.IssueAt(clock.Now())
+}
+
+// Issue at the given time rather than clock.Now, see Issue.
+func (caa *Timeout) IssueAt(at time.Time) SessionCAA {
+	now := at.Unix()
 
 	if !caa.HasIssued() {
 		caa.set(now)
@@ -70,6 +131,23 @@ func (caa *Timeout) Issue() SessionCAA {
 	return SessionCAA(now)
 }
 
+// Validates old and if valid issues its replacement, e.g. when refreshing a
+// session. Old can't be revoked without revoking every session issued before
+// it so remains valid until it expires. Returns the reason old is invalid
+// otherwise, see Validate.
+func (caa *Timeout) Rotate(old SessionCAA, durationSecs int64) (SessionCAA, error) {
+	return caa.RotateAt(old, durationSecs, clock.Now())
+}
+
+// Rotate at the given time rather than clock.Now, see Rotate.
+func (caa *Timeout) RotateAt(old SessionCAA, durationSecs int64, at time.Time) (SessionCAA, error) {
+	if err := caa.ValidateAt(old, durationSecs, at); err != nil {
+		return 0, err
+	}
+
+	return caa.IssueAt(at), nil
+}
+
 // Indicates if the CAA has issued at least once, regardless if it has been
 // locked.
 func (caa Timeout) HasIssued() bool {
@@ -88,4 +166,81 @@ func (caa *Timeout) set(i int64) {
 	}
 }
 
-var _ = CAA(NewCounter())
+func (caa Timeout) raw() State {
+	return State{Value: int64(caa), Known: true}
+}
+
+// ClockedTimeout is a Timeout that reads the current time from a Clock rather
+// than the package level clock.Now, allowing tests to run in parallel with
+// their own clocks. Leeway is the number of seconds clocks across servers may
+// be out of sync by, see ValidateAtWithLeeway. With the default Leeway of 0 it
+// validates as Timeout.Validate does, accepting sessions issued after now.
+type ClockedTimeout struct {
+	Timeout Timeout
+	Clock   clock.Clock
+	Leeway  int64
+}
+
+func NewTimeoutWithClock(c clock.Clock) *ClockedTimeout {
+	return &ClockedTimeout{Clock: c}
+}
+
+// Locks CAA to prevent validation of session CAA's.
+func (caa *ClockedTimeout) Lock() {
+	caa.Timeout.Lock()
+}
+
+// Unlocks CAA to allow validation of session CAA's.
+func (caa *ClockedTimeout) Unlock() {
+	caa.Timeout.Unlock()
+}
+
+func (caa *ClockedTimeout) IsLocked() bool {
+	return caa.Timeout.IsLocked()
+}
+
+func (caa *ClockedTimeout) IsValid(s SessionCAA, durationSecs int64) bool {
+	return caa.Validate(s, durationSecs) == nil
+}
+
+func (caa *ClockedTimeout) Validate(s SessionCAA, durationSecs int64) error {
+	return caa.validateAt(s, durationSecs, caa.Clock.Now())
+}
+
+func (caa *ClockedTimeout) Issue() SessionCAA {
+	return caa.Timeout.IssueAt(caa.Clock.Now())
+}
+
+// Invalidates all sessions issued before expiryTimestamp, see
+// Timeout.Revoke.
+func (caa *ClockedTimeout) Revoke(expiryTimestamp int64) {
+	caa.Timeout.Revoke(expiryTimestamp)
+}
+
+// Indicates if the CAA has issued at least once, regardless if it has been
+// locked.
+func (caa *ClockedTimeout) HasIssued() bool {
+	return caa.Timeout.HasIssued()
+}
+
+func (caa *ClockedTimeout) Rotate(old SessionCAA, durationSecs int64) (SessionCAA, error) {
+	now := caa.Clock.Now()
+	if err := caa.validateAt(old, durationSecs, now); err != nil {
+		return 0, err
+	}
+
+	return caa.Timeout.IssueAt(now), nil
+}
+
+// Sessions from the future are only rejected once a Leeway is set.
+func (caa *ClockedTimeout) validateAt(s SessionCAA, durationSecs int64, at time.Time) error {
+	return caa.Timeout.validateAt(s, durationSecs, caa.Leeway, at, caa.Leeway != 0)
+}
+
+func (caa *ClockedTimeout) raw() State {
+	return caa.Timeout.raw()
+}
+
+var _ = CAA(NewTimeout())
+var _ = CAA(NewTimeoutWithClock(clock.Real{}))
+var _ = Rotator(NewTimeout())
@@ -0,0 +1,158 @@
+package compandauth
+
+import (
+	"time"
+
+	"github.com/endiangroup/compandauth/clock"
+)
+
+// TimeoutMillis is a Timeout with millisecond rather than second precision,
+// both the CAA and the session CAAs it issues are unix timestamps in
+// milliseconds. Use it when sessions need to be valid for short or precise
+// durations, or when a Revoke followed by an Issue within the same second
+// must reliably produce a valid session.
+type TimeoutMillis int64
+
+func NewTimeoutMillis() *TimeoutMillis {
+	return new(TimeoutMillis)
+}
+
+// Converts a second precision Timeout into a TimeoutMillis, preserving whether
+// it is locked.
+func TimeoutMillisFromSeconds(caa Timeout) TimeoutMillis {
+	return TimeoutMillis(int64(caa) * 1000)
+}
+
+// Converts a session CAA issued by a Timeout into one that can be validated
+// by a TimeoutMillis migrated with TimeoutMillisFromSeconds. The session is
+// treated as issued at the very start of its second.
+func SessionCAAFromSeconds(s SessionCAA) SessionCAA {
+	return s * 1000
+}
+
+// Utility function to convert time.Duration into int64 milliseconds
+func ToMillis(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
+
+// Locks CAA to prevent validation of session CAA's.
+func (caa *TimeoutMillis) Lock() {
+	*caa = -caa.abs()
+}
+
+// Unlocks CAA to allow validation of session CAA's.
+func (caa *TimeoutMillis) Unlock() {
+	*caa = caa.abs()
+}
+
+func (caa TimeoutMillis) IsLocked() bool {
+	return caa < 0
+}
+
+// Indicates if an session CAA is considered valid. s should be the CAA value
+// retrieved from a session token (e.g. JWT). durationMillis represents
+// number of milliseconds you would like to consider a session valid for.
+func (caa TimeoutMillis) IsValid(s SessionCAA, durationMillis int64) bool {
+	return caa.Validate(s, durationMillis) == nil
+}
+
+// Validate behaves as IsValid but returns the reason a session CAA is
+// considered invalid, see Timeout.Validate.
+func (caa TimeoutMillis) Validate(s SessionCAA, durationMillis int64) error {
+	return caa.ValidateAt(s, durationMillis, clock.Now())
+}
+
+// Indicates if a session CAA is considered valid at the given time, see
+// IsValid.
+func (caa TimeoutMillis) IsValidAt(s SessionCAA, durationMillis int64, at time.Time) bool {
+	return caa.ValidateAt(s, durationMillis, at) == nil
+}
+
+// Validate at the given time rather than clock.Now, see Validate.
+func (caa TimeoutMillis) ValidateAt(s SessionCAA, durationMillis int64, at time.Time) error {
+	return caa.validateAt(s, durationMillis, 0, at, false)
+}
+
+// Validate at the given time allowing for clocks across servers being up to
+// leewayMillis out of sync, see Timeout.ValidateAtWithLeeway.
+func (caa TimeoutMillis) ValidateAtWithLeeway(s SessionCAA, durationMillis, leewayMillis int64, at time.Time) error {
+	return caa.validateAt(s, durationMillis, leewayMillis, at, true)
+}
+
+func (caa TimeoutMillis) validateAt(s SessionCAA, durationMillis, leewayMillis int64, at time.Time, rejectFuture bool) error {
+	sessionTimestamp := abs(int64(s))
+	durationMillis = abs(durationMillis)
+	leewayMillis = abs(leewayMillis)
+	expiryTimestamp := int64(caa.abs())
+	now := unixMillis(at)
+
+	switch {
+	case caa.IsLocked():
+		return ErrLocked
+	case !caa.HasIssued():
+		return ErrNeverIssued
+	case rejectFuture && sessionTimestamp > now+leewayMillis:
+		return ErrFutureSession
+	case sessionTimestamp+leewayMillis < expiryTimestamp:
+		return ErrRevoked
+	case (sessionTimestamp + durationMillis + leewayMillis) < now:
+		return ErrExpired
+	}
+
+	return nil
+}
+
+// Invalidates all sessions issued before expiryTimestamp (which should be a
+// unix timestamp in milliseconds), see Timeout.Revoke.
+func (caa *TimeoutMillis) Revoke(expiryTimestamp int64) {
+	if !caa.HasIssued() {
+		return
+	}
+
+	caa.set(expiryTimestamp)
+}
+
+// Issues the next CAA value to use in a distributed session, see
+// Timeout.Issue.
+func (caa *TimeoutMillis) Issue() SessionCAA {
+	return caa.IssueAt(clock.Now())
+}
+
+// Issue at the given time rather than clock.Now, see Issue.
+func (caa *TimeoutMillis) IssueAt(at time.Time) SessionCAA {
+	now := unixMillis(at)
+
+	if !caa.HasIssued() {
+		caa.set(now)
+	}
+
+	return SessionCAA(now)
+}
+
+// Indicates if the CAA has issued at least once, regardless if it has been
+// locked.
+func (caa TimeoutMillis) HasIssued() bool {
+	return caa != 0
+}
+
+func (caa TimeoutMillis) abs() TimeoutMillis {
+	return TimeoutMillis(abs(int64(caa)))
+}
+
+func (caa *TimeoutMillis) set(i int64) {
+	if caa.IsLocked() {
+		*caa = TimeoutMillis(-abs(i))
+	} else {
+		*caa = TimeoutMillis(abs(i))
+	}
+}
+
+func unixMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func (caa TimeoutMillis) raw() State {
+	return State{Value: int64(caa), Known: true}
+}
+
+var _ = CAA(NewTimeoutMillis())
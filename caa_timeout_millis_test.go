@@ -0,0 +1,107 @@
+package compandauth
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_TimeoutMillis_RevokeThenIssueWithinTheSameSecondIsValid(t *testing.T) {
+	at := time.Unix(1500000000, 0)
+	caa := NewTimeoutMillis()
+	old := caa.IssueAt(at)
+
+	caa.Revoke(unixMillis(at.Add(100 * time.Millisecond)))
+	new := caa.IssueAt(at.Add(200 * time.Millisecond))
+
+	assert.Equal(t, ErrRevoked, caa.ValidateAt(old, ToMillis(time.Minute), at.Add(300*time.Millisecond)))
+	assert.NoError(t, caa.ValidateAt(new, ToMillis(time.Minute), at.Add(300*time.Millisecond)))
+}
+
+func Test_TimeoutMillis_ValidateAtReturnsReasonSessionCAAIsInvalid(t *testing.T) {
+	at := time.Unix(1500000000, 0)
+	now := unixMillis(at)
+
+	tests := []struct {
+		CAA            TimeoutMillis
+		SessionCAA     SessionCAA
+		DurationMillis int64
+		ExpectedErr    error
+	}{
+		{CAA: -1, SessionCAA: SessionCAA(now), DurationMillis: 10, ExpectedErr: ErrLocked},
+		{CAA: 0, SessionCAA: SessionCAA(now), DurationMillis: 10, ExpectedErr: ErrNeverIssued},
+		{CAA: 1, SessionCAA: SessionCAA(now + 1), DurationMillis: 10, ExpectedErr: nil},
+		{CAA: TimeoutMillis(now), SessionCAA: SessionCAA(now - 1), DurationMillis: 10, ExpectedErr: ErrRevoked},
+		{CAA: 1, SessionCAA: SessionCAA(now - 11), DurationMillis: 10, ExpectedErr: ErrExpired},
+		{CAA: 1, SessionCAA: SessionCAA(now - 10), DurationMillis: 10, ExpectedErr: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%+v", test), func(t *testing.T) {
+			assert.Equal(t, test.ExpectedErr, test.CAA.ValidateAt(test.SessionCAA, test.DurationMillis, at))
+			assert.Equal(t, test.ExpectedErr == nil, test.CAA.IsValidAt(test.SessionCAA, test.DurationMillis, at))
+		})
+	}
+}
+
+func Test_TimeoutMillis_ValidateAtWithLeewayReturnsErrFutureSessionIfSessionCAAIsAfterAtPlusLeeway(t *testing.T) {
+	at := time.Unix(1500000000, 0)
+	now := unixMillis(at)
+
+	assert.Equal(t, ErrFutureSession, TimeoutMillis(1).ValidateAtWithLeeway(SessionCAA(now+1), 10, 0, at))
+	assert.NoError(t, TimeoutMillis(1).ValidateAtWithLeeway(SessionCAA(now+1), 10, 1, at))
+}
+
+func Test_TimeoutMillis_LockUnlockAndRevokePreserveState(t *testing.T) {
+	caa := TimeoutMillis(5)
+
+	caa.Lock()
+	assert.Equal(t, TimeoutMillis(-5), caa)
+
+	caa.Revoke(10)
+	assert.Equal(t, TimeoutMillis(-10), caa)
+
+	caa.Unlock()
+	assert.Equal(t, TimeoutMillis(10), caa)
+	assert.True(t, caa.HasIssued())
+
+	unissued := NewTimeoutMillis()
+	unissued.Revoke(10)
+	assert.False(t, unissued.HasIssued())
+}
+
+func Test_TimeoutMillisFromSeconds_PreservesTimestampAndLock(t *testing.T) {
+	tests := []struct {
+		CAA      Timeout
+		Expected TimeoutMillis
+	}{
+		{CAA: 0, Expected: 0},
+		{CAA: 1500000000, Expected: 1500000000000},
+		{CAA: -1500000000, Expected: -1500000000000},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%+v", test), func(t *testing.T) {
+			assert.Equal(t, test.Expected, TimeoutMillisFromSeconds(test.CAA))
+		})
+	}
+}
+
+func Test_SessionCAAFromSeconds_RemainsValidAfterMigration(t *testing.T) {
+	at := time.Unix(1500000000, 500*int64(time.Millisecond))
+	caa := NewTimeout()
+	s := caa.IssueAt(at)
+
+	migrated := TimeoutMillisFromSeconds(*caa)
+
+	assert.Equal(t, ErrRevoked, migrated.ValidateAt(s, math.MaxInt32, at))
+	assert.NoError(t, migrated.ValidateAt(SessionCAAFromSeconds(s), ToMillis(time.Second), at))
+}
+
+func Test_ToMillis_ConvertsDuration(t *testing.T) {
+	assert.Equal(t, int64(1500), ToMillis(1500*time.Millisecond))
+	assert.Equal(t, int64(0), ToMillis(time.Microsecond))
+}
@@ -1,6 +1,7 @@
 package compandauth
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"testing"
@@ -155,6 +156,53 @@ func Test_IsValid_ReturnsTrueIfSessionCAAPlusDeltaIsAfterOrEqualToNow(t *testing
 	assert.True(t, Timeout(1).IsValid(now-10, 10))
 }
 
+func Test_IsValid_ReturnsTrueIfSessionCAAIsAfterNow(t *testing.T) {
+	now := time.Now()
+	clock.NowForce(now)
+	defer clock.NowReset()
+
+	assert.True(t, Timeout(1).IsValid(SessionCAA(now.Unix()+1), 10))
+}
+
+func Test_ValidateWithLeeway_ReturnsErrFutureSessionIfSessionCAAIsAfterNowPlusLeeway(t *testing.T) {
+	now := time.Now()
+	clock.NowForce(now)
+	defer clock.NowReset()
+
+	assert.Equal(t, ErrFutureSession, Timeout(1).ValidateWithLeeway(SessionCAA(now.Unix()+1), 10, 0))
+	assert.NoError(t, Timeout(1).ValidateWithLeeway(SessionCAA(now.Unix()+1), 10, 1))
+}
+
+func Test_Validate_ReturnsReasonSessionCAAIsInvalidForTimeoutCAA(t *testing.T) {
+	now := time.Now()
+	clock.NowForce(now)
+	defer clock.NowReset()
+
+	tests := []struct {
+		CAA          *Timeout
+		SessionCAA   SessionCAA
+		DurationSecs int64
+		ExpectedErr  error
+	}{
+		{CAA: setTimeoutCAA(-1), SessionCAA: SessionCAA(now.Unix()), DurationSecs: 10, ExpectedErr: ErrLocked},
+		{CAA: setTimeoutCAA(0), SessionCAA: SessionCAA(now.Unix()), DurationSecs: 10, ExpectedErr: ErrNeverIssued},
+		{CAA: setTimeoutCAA(1), SessionCAA: SessionCAA(now.Unix() + 1), DurationSecs: 10, ExpectedErr: nil},
+		{CAA: setTimeoutCAA(now.Unix()), SessionCAA: SessionCAA(now.Unix() - 1), DurationSecs: 10, ExpectedErr: ErrRevoked},
+		{CAA: setTimeoutCAA(1), SessionCAA: SessionCAA(now.Unix() - 11), DurationSecs: 10, ExpectedErr: ErrExpired},
+		{CAA: setTimeoutCAA(1), SessionCAA: SessionCAA(now.Unix() - 10), DurationSecs: 10, ExpectedErr: nil},
+		{CAA: setTimeoutCAA(now.Unix()), SessionCAA: SessionCAA(now.Unix()), DurationSecs: 0, ExpectedErr: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%+v", test), func(t *testing.T) {
+			err := test.CAA.Validate(test.SessionCAA, test.DurationSecs)
+
+			assert.True(t, errors.Is(err, test.ExpectedErr))
+			assert.Equal(t, err == nil, test.CAA.IsValid(test.SessionCAA, test.DurationSecs))
+		})
+	}
+}
+
 func Test_Issue_SetsTimeCAAToNowOnFirstIssue(t *testing.T) {
 	now := time.Now()
 	clock.NowForce(now)
@@ -273,3 +321,153 @@ func Test_Revoke_ReturnsNAsTimeCAA(t *testing.T) {
 		})
 	}
 }
+
+func Test_IssueAt_SetsTimeCAAToAtOnFirstIssue(t *testing.T) {
+	t.Parallel()
+	at := time.Unix(1500000000, 0)
+	caa := NewTimeout()
+
+	sessionCAA := caa.IssueAt(at)
+	caa.IssueAt(at.Add(time.Minute))
+
+	assert.Equal(t, SessionCAA(at.Unix()), sessionCAA)
+	assert.Equal(t, setTimeoutCAA(at.Unix()), caa)
+}
+
+func Test_ValidateAt_ValidatesAgainstAt(t *testing.T) {
+	t.Parallel()
+	at := time.Unix(1500000000, 0)
+	caa := setTimeoutCAA(at.Unix())
+	sessionCAA := SessionCAA(at.Unix())
+
+	assert.NoError(t, caa.ValidateAt(sessionCAA, 10, at.Add(10*time.Second)))
+	assert.True(t, caa.IsValidAt(sessionCAA, 10, at.Add(10*time.Second)))
+	assert.Equal(t, ErrExpired, caa.ValidateAt(sessionCAA, 10, at.Add(11*time.Second)))
+	assert.NoError(t, caa.ValidateAt(sessionCAA, 10, at.Add(-time.Second)))
+	assert.Equal(t, ErrFutureSession, caa.ValidateAtWithLeeway(sessionCAA, 10, 0, at.Add(-time.Second)))
+}
+
+func Test_ClockedTimeout_UsesItsClock(t *testing.T) {
+	t.Parallel()
+	fake := clock.NewFake(time.Unix(1500000000, 0))
+	caa := NewTimeoutWithClock(fake)
+
+	sessionCAA := caa.Issue()
+	assert.Equal(t, SessionCAA(1500000000), sessionCAA)
+	assert.True(t, caa.IsValid(sessionCAA, 30))
+
+	fake.Advance(31 * time.Second)
+	assert.Equal(t, ErrExpired, caa.Validate(sessionCAA, 30))
+
+	caa.Revoke(fake.Now().Unix())
+	newSessionCAA := caa.Issue()
+	assert.Equal(t, ErrRevoked, caa.Validate(sessionCAA, 60))
+	assert.NoError(t, caa.Validate(newSessionCAA, 60))
+
+	caa.Lock()
+	assert.Equal(t, ErrLocked, caa.Validate(newSessionCAA, 60))
+}
+
+func Test_ValidateAtWithLeeway_ToleratesSkewUpToLeeway(t *testing.T) {
+	t.Parallel()
+	at := time.Unix(1500000000, 0)
+	revokedAt := at.Unix() - 5
+
+	tests := []struct {
+		SessionCAA  SessionCAA
+		Leeway      int64
+		ExpectedErr error
+	}{
+		// Issued on a server 2s ahead
+		{SessionCAA: SessionCAA(at.Unix() + 2), Leeway: 0, ExpectedErr: ErrFutureSession},
+		{SessionCAA: SessionCAA(at.Unix() + 2), Leeway: 2, ExpectedErr: nil},
+		{SessionCAA: SessionCAA(at.Unix() + 3), Leeway: 2, ExpectedErr: ErrFutureSession},
+		{SessionCAA: SessionCAA(at.Unix() + 2), Leeway: -2, ExpectedErr: nil},
+		// Issued on a server 2s behind the one that revoked
+		{SessionCAA: SessionCAA(revokedAt - 2), Leeway: 0, ExpectedErr: ErrRevoked},
+		{SessionCAA: SessionCAA(revokedAt - 2), Leeway: 2, ExpectedErr: nil},
+		{SessionCAA: SessionCAA(revokedAt - 3), Leeway: 2, ExpectedErr: ErrRevoked},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%+v", test), func(t *testing.T) {
+			caa := setTimeoutCAA(revokedAt)
+
+			assert.Equal(t, test.ExpectedErr, caa.ValidateAtWithLeeway(test.SessionCAA, 60, test.Leeway, at))
+		})
+	}
+}
+
+func Test_ValidateAtWithLeeway_ExtendsExpiryByLeeway(t *testing.T) {
+	t.Parallel()
+	at := time.Unix(1500000000, 0)
+	caa := setTimeoutCAA(1)
+	sessionCAA := SessionCAA(at.Unix() - 12)
+
+	assert.Equal(t, ErrExpired, caa.ValidateAtWithLeeway(sessionCAA, 10, 1, at))
+	assert.NoError(t, caa.ValidateAtWithLeeway(sessionCAA, 10, 2, at))
+}
+
+func Test_ClockedTimeout_ValidatesWithLeeway(t *testing.T) {
+	t.Parallel()
+	fake := clock.NewFake(time.Unix(1500000000, 0))
+	ahead := NewTimeoutWithClock(clock.NewOffset(fake, 2*time.Second))
+	behind := NewTimeoutWithClock(fake)
+	behind.Leeway = 2
+
+	sessionCAA := ahead.Issue()
+	behind.Timeout = ahead.Timeout
+
+	assert.NoError(t, behind.Validate(sessionCAA, 30))
+
+	behind.Leeway = 1
+	assert.Equal(t, ErrFutureSession, behind.Validate(sessionCAA, 30))
+}
+
+func Test_ClockedTimeout_AcceptsSessionsAfterNowWithoutLeeway(t *testing.T) {
+	t.Parallel()
+	fake := clock.NewFake(time.Unix(1500000000, 0))
+	caa := NewTimeoutWithClock(fake)
+	caa.Issue()
+	sessionCAA := SessionCAA(fake.Now().Unix() + 1)
+
+	assert.NoError(t, caa.Validate(sessionCAA, 30))
+	assert.Equal(t, caa.Timeout.ValidateAt(sessionCAA, 30, fake.Now()), caa.Validate(sessionCAA, 30))
+
+	_, err := caa.Rotate(sessionCAA, 30)
+	assert.NoError(t, err)
+}
+
+func Test_Rotate_IssuesReplacementForValidTimeoutSession(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	clock.NowForce(now)
+	defer clock.NowReset()
+	caa := setTimeoutCAA(now.Unix() - 100)
+
+	s, err := caa.Rotate(SessionCAA(now.Unix()-50), 60)
+	assert.NoError(t, err)
+	assert.Equal(t, SessionCAA(now.Unix()), s)
+	assert.Equal(t, setTimeoutCAA(now.Unix()-100), caa)
+
+	_, err = caa.Rotate(SessionCAA(now.Unix()-61), 60)
+	assert.Equal(t, ErrExpired, err)
+
+	caa.Lock()
+	_, err = caa.Rotate(s, 60)
+	assert.Equal(t, ErrLocked, err)
+}
+
+func Test_Rotate_UsesClockOfClockedTimeout(t *testing.T) {
+	fake := clock.NewFake(time.Unix(1500000000, 0))
+	caa := NewTimeoutWithClock(fake)
+	s := caa.Issue()
+
+	fake.Advance(30 * time.Second)
+	next, err := caa.Rotate(s, 60)
+	assert.NoError(t, err)
+	assert.Equal(t, SessionCAA(1500000030), next)
+
+	fake.Advance(61 * time.Second)
+	_, err = caa.Rotate(s, 60)
+	assert.Equal(t, ErrExpired, err)
+}
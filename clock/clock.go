@@ -1,12 +1,17 @@
 package clock
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 var (
 	// Force to UTC so no possible timzone conflicts across servers
 	Now = func() time.Time { return time.Now().UTC() }
 )
 
+// Replaces Now, not safe to use concurrently with anything reading Now so
+// prefer passing a Clock explicitly (e.g. a Fake) in new code.
 func NowForce(t time.Time) {
 	Now = func() time.Time { return t }
 }
@@ -14,3 +19,67 @@ func NowForce(t time.Time) {
 func NowReset() {
 	Now = func() time.Time { return time.Now().UTC() }
 }
+
+// Clock is a source of the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// Global is a Clock reading the package level Now, so it honours NowForce.
+type Global struct{}
+
+func (Global) Now() time.Time {
+	return Now()
+}
+
+// Real is a Clock returning the current time in UTC.
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// Fake is a Clock that only moves when told to, it is safe for concurrent
+// use.
+type Fake struct {
+	mu sync.RWMutex
+	t  time.Time
+}
+
+func NewFake(t time.Time) *Fake {
+	return &Fake{t: t}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.t
+}
+
+func (f *Fake) Set(t time.Time) {
+	f.mu.Lock()
+	f.t = t
+	f.mu.Unlock()
+}
+
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	f.t = f.t.Add(d)
+	f.mu.Unlock()
+}
+
+// Offset is a Clock running a fixed duration ahead (or behind if negative) of
+// another Clock, e.g. to simulate skew between servers.
+type Offset struct {
+	Clock  Clock
+	Offset time.Duration
+}
+
+func NewOffset(c Clock, d time.Duration) Offset {
+	return Offset{Clock: c, Offset: d}
+}
+
+func (o Offset) Now() time.Time {
+	return o.Clock.Now().Add(o.Offset)
+}
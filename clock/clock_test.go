@@ -0,0 +1,44 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Real_ReturnsCurrentTimeInUTC(t *testing.T) {
+	before := time.Now()
+	now := Real{}.Now()
+
+	assert.Equal(t, time.UTC, now.Location())
+	assert.False(t, now.Before(before.Add(-time.Second)))
+}
+
+func Test_Fake_OnlyMovesWhenSetOrAdvanced(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	f := NewFake(start)
+
+	assert.Equal(t, start, f.Now())
+
+	f.Advance(5 * time.Second)
+	assert.Equal(t, start.Add(5*time.Second), f.Now())
+
+	f.Set(start)
+	assert.Equal(t, start, f.Now())
+}
+
+func Test_Offset_AddsOffsetToClock(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+
+	assert.Equal(t, start.Add(2*time.Second), NewOffset(NewFake(start), 2*time.Second).Now())
+	assert.Equal(t, start.Add(-2*time.Second), NewOffset(NewFake(start), -2*time.Second).Now())
+}
+
+func Test_Global_HonoursNowForce(t *testing.T) {
+	start := time.Unix(1500000000, 0)
+	NowForce(start)
+	defer NowReset()
+
+	assert.Equal(t, start, Global{}.Now())
+}
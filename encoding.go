@@ -0,0 +1,256 @@
+package compandauth
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrMalformedCAA is returned when unmarshalling a CAA or session CAA that is
+// malformed, or whose fields are inconsistent with each other or the type
+// being unmarshalled into.
+var ErrMalformedCAA = errors.New("compandauth: malformed CAA")
+
+// Counter, Timeout and TimeoutMillis marshal to their compact form by
+// default, the raw signed integer (e.g. 12 or -12 if locked), so they can be
+// persisted losslessly. Wrap them in Structured to marshal to the structured
+// form, which doesn't expose that a negative value means locked:
+//
+//   JSON: {"type":"counter","value":12,"locked":true}
+//   Text: counter:12:locked
+//
+// Unmarshalling accepts either form.
+
+// MarshalJSON implements json.Marshaler in the compact form.
+func (caa Counter) MarshalJSON() ([]byte, error) {
+	return caa.MarshalText()
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting either form.
+func (caa *Counter) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(KindCounter, (*int64)(caa), b)
+}
+
+// MarshalText implements encoding.TextMarshaler in the compact form.
+func (caa Counter) MarshalText() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(caa), 10), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting either form.
+func (caa *Counter) UnmarshalText(b []byte) error {
+	return unmarshalText(KindCounter, (*int64)(caa), b)
+}
+
+// MarshalJSON implements json.Marshaler in the compact form.
+func (caa Timeout) MarshalJSON() ([]byte, error) {
+	return caa.MarshalText()
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting either form.
+func (caa *Timeout) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(KindTimeout, (*int64)(caa), b)
+}
+
+// MarshalText implements encoding.TextMarshaler in the compact form.
+func (caa Timeout) MarshalText() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(caa), 10), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting either form.
+func (caa *Timeout) UnmarshalText(b []byte) error {
+	return unmarshalText(KindTimeout, (*int64)(caa), b)
+}
+
+// MarshalJSON implements json.Marshaler in the compact form.
+func (caa TimeoutMillis) MarshalJSON() ([]byte, error) {
+	return caa.MarshalText()
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting either form.
+func (caa *TimeoutMillis) UnmarshalJSON(b []byte) error {
+	return unmarshalJSON(KindTimeoutMillis, (*int64)(caa), b)
+}
+
+// MarshalText implements encoding.TextMarshaler in the compact form.
+func (caa TimeoutMillis) MarshalText() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(caa), 10), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting either form.
+func (caa *TimeoutMillis) UnmarshalText(b []byte) error {
+	return unmarshalText(KindTimeoutMillis, (*int64)(caa), b)
+}
+
+// MarshalJSON implements json.Marshaler as a JSON number.
+func (s SessionCAA) MarshalJSON() ([]byte, error) {
+	return s.MarshalText()
+}
+
+// UnmarshalJSON implements json.Unmarshaler from a JSON number.
+func (s *SessionCAA) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	return s.UnmarshalText(b)
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (s SessionCAA) MarshalText() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(s), 10), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (s *SessionCAA) UnmarshalText(b []byte) error {
+	i, err := scanInt64(string(b))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedCAA, err)
+	}
+
+	*s = SessionCAA(i)
+	return nil
+}
+
+// Structured marshals a *Counter, *Timeout or *TimeoutMillis in the
+// structured form, and unmarshals either form into it.
+type Structured struct {
+	CAA CAA
+}
+
+type structuredJSON struct {
+	Type   Kind   `json:"type"`
+	Value  *int64 `json:"value"`
+	Locked bool   `json:"locked"`
+}
+
+// MarshalJSON implements json.Marshaler in the structured form.
+func (s Structured) MarshalJSON() ([]byte, error) {
+	t, v, err := s.raw()
+	if err != nil {
+		return nil, err
+	}
+
+	value := abs(*v)
+	return json.Marshal(structuredJSON{Type: t, Value: &value, Locked: *v < 0})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting either form.
+func (s *Structured) UnmarshalJSON(b []byte) error {
+	t, v, err := s.raw()
+	if err != nil {
+		return err
+	}
+
+	return unmarshalJSON(t, v, b)
+}
+
+// MarshalText implements encoding.TextMarshaler in the structured form.
+func (s Structured) MarshalText() ([]byte, error) {
+	t, v, err := s.raw()
+	if err != nil {
+		return nil, err
+	}
+
+	b := strconv.AppendInt(append([]byte(t), ':'), abs(*v), 10)
+	if *v < 0 {
+		b = append(b, ":locked"...)
+	}
+
+	return b, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting either form.
+func (s *Structured) UnmarshalText(b []byte) error {
+	t, v, err := s.raw()
+	if err != nil {
+		return err
+	}
+
+	return unmarshalText(t, v, b)
+}
+
+func (s Structured) raw() (Kind, *int64, error) {
+	switch c := s.CAA.(type) {
+	case *Counter:
+		return KindCounter, (*int64)(c), nil
+	case *Timeout:
+		return KindTimeout, (*int64)(c), nil
+	case *TimeoutMillis:
+		return KindTimeoutMillis, (*int64)(c), nil
+	}
+
+	return "", nil, fmt.Errorf("compandauth: unsupported structured CAA %T", s.CAA)
+}
+
+func unmarshalJSON(t Kind, v *int64, b []byte) error {
+	b = bytes.TrimSpace(b)
+	if string(b) == "null" {
+		return nil
+	}
+	if len(b) == 0 || b[0] != '{' {
+		return unmarshalCompact(v, string(b))
+	}
+
+	var sj structuredJSON
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sj); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedCAA, err)
+	}
+	if sj.Value == nil {
+		return fmt.Errorf("%w: missing value", ErrMalformedCAA)
+	}
+
+	return unmarshalStructured(t, v, sj.Type, *sj.Value, sj.Locked)
+}
+
+func unmarshalText(t Kind, v *int64, b []byte) error {
+	parts := strings.Split(string(b), ":")
+	switch {
+	case len(parts) == 1:
+		return unmarshalCompact(v, parts[0])
+	case len(parts) > 3 || (len(parts) == 3 && parts[2] != "locked"):
+		return fmt.Errorf("%w: %q", ErrMalformedCAA, b)
+	}
+
+	value, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedCAA, err)
+	}
+
+	return unmarshalStructured(t, v, Kind(parts[0]), value, len(parts) == 3)
+}
+
+func unmarshalCompact(v *int64, s string) error {
+	i, err := scanInt64(s)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedCAA, err)
+	}
+
+	*v = i
+	return nil
+}
+
+// Sets v from the structured form, rejecting types other than t and values
+// that can't be represented, i.e. negative values or a locked CAA that has
+// never issued.
+func unmarshalStructured(t Kind, v *int64, gotType Kind, value int64, locked bool) error {
+	switch {
+	case gotType != t:
+		return fmt.Errorf("%w: type %q, expected %q", ErrMalformedCAA, gotType, t)
+	case value < 0:
+		return fmt.Errorf("%w: negative value %d", ErrMalformedCAA, value)
+	case locked && value == 0:
+		return fmt.Errorf("%w: locked but never issued", ErrMalformedCAA)
+	}
+
+	if locked {
+		value = -value
+	}
+
+	*v = value
+	return nil
+}
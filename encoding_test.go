@@ -0,0 +1,182 @@
+package compandauth
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_MarshalJSON_CompactFormIsRawValue(t *testing.T) {
+	b, err := json.Marshal(struct {
+		Counter       Counter
+		Timeout       *Timeout
+		TimeoutMillis TimeoutMillis
+		Session       SessionCAA
+	}{Counter(-12), setTimeoutCAA(1500000000), TimeoutMillis(0), SessionCAA(11)})
+
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Counter":-12,"Timeout":1500000000,"TimeoutMillis":0,"Session":11}`, string(b))
+}
+
+func Test_MarshalJSON_StructuredForm(t *testing.T) {
+	millis := TimeoutMillis(1500000000000)
+	tests := []struct {
+		CAA      CAA
+		Expected string
+	}{
+		{CAA: setCounterCAA(12), Expected: `{"type":"counter","value":12,"locked":false}`},
+		{CAA: setCounterCAA(-12), Expected: `{"type":"counter","value":12,"locked":true}`},
+		{CAA: setTimeoutCAA(-1500000000), Expected: `{"type":"timeout","value":1500000000,"locked":true}`},
+		{CAA: &millis, Expected: `{"type":"timeout-millis","value":1500000000000,"locked":false}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Expected, func(t *testing.T) {
+			b, err := json.Marshal(Structured{test.CAA})
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, string(b))
+		})
+	}
+}
+
+func Test_MarshalText_StructuredForm(t *testing.T) {
+	b, err := Structured{setCounterCAA(-12)}.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "counter:12:locked", string(b))
+
+	b, err = Structured{setTimeoutCAA(1500000000)}.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "timeout:1500000000", string(b))
+}
+
+func Test_Structured_RejectsUnsupportedCAA(t *testing.T) {
+	_, err := json.Marshal(Structured{NewSliding(0)})
+	assert.Error(t, err)
+}
+
+func Test_UnmarshalJSON_RoundTripsBothForms(t *testing.T) {
+	values := []int64{0, 1, -1, 12, -12, 1500000000, math.MaxInt64, -math.MaxInt64}
+
+	for _, v := range values {
+		caa := Counter(v)
+
+		compact, err := json.Marshal(caa)
+		assert.NoError(t, err)
+		structured, err := json.Marshal(Structured{&caa})
+		assert.NoError(t, err)
+		text, err := Structured{&caa}.MarshalText()
+		assert.NoError(t, err)
+
+		for _, b := range [][]byte{compact, structured} {
+			var got Counter
+			assert.NoError(t, json.Unmarshal(b, &got), string(b))
+			assert.Equal(t, caa, got, string(b))
+
+			got = 0
+			assert.NoError(t, json.Unmarshal(b, &Structured{&got}), string(b))
+			assert.Equal(t, caa, got, string(b))
+		}
+
+		for _, b := range [][]byte{compact, text} {
+			var got Counter
+			assert.NoError(t, got.UnmarshalText(b), string(b))
+			assert.Equal(t, caa, got, string(b))
+		}
+	}
+}
+
+func Test_UnmarshalJSON_SessionCAARoundTrips(t *testing.T) {
+	var s SessionCAA
+	assert.NoError(t, json.Unmarshal([]byte(`42`), &s))
+	assert.Equal(t, SessionCAA(42), s)
+
+	b, err := s.MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "42", string(b))
+}
+
+func Test_UnmarshalJSON_LeavesCAAUnchangedOnNull(t *testing.T) {
+	caa := setTimeoutCAA(5)
+	assert.NoError(t, json.Unmarshal([]byte(`null`), caa))
+	assert.Equal(t, setTimeoutCAA(5), caa)
+}
+
+func Test_UnmarshalJSON_RejectsInconsistentInput(t *testing.T) {
+	tests := []string{
+		`{"type":"timeout","value":12,"locked":false}`,
+		`{"value":12}`,
+		`{"type":"counter"}`,
+		`{"type":"counter","value":-12,"locked":true}`,
+		`{"type":"counter","value":0,"locked":true}`,
+		`{"type":"counter","value":12,"locked":true,"extra":1}`,
+		`{"type":"counter","value":1.5}`,
+		`"12"`,
+		`1.5`,
+		`1e3`,
+		`-9223372036854775808`,
+		`9223372036854775808`,
+		`true`,
+	}
+
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			caa := setCounterCAA(7)
+			err := json.Unmarshal([]byte(test), caa)
+			assert.Error(t, err)
+			assert.True(t, errors.Is(err, ErrMalformedCAA), "%v", err)
+			assert.Equal(t, setCounterCAA(7), caa)
+		})
+	}
+}
+
+func Test_UnmarshalText_RejectsInconsistentInput(t *testing.T) {
+	tests := []string{
+		"",
+		"timeout:12",
+		"counter:-12:locked",
+		"counter:0:locked",
+		"counter:12:unlocked",
+		"counter:12:locked:again",
+		"counter:abc",
+		"12abc",
+	}
+
+	for _, test := range tests {
+		t.Run(test, func(t *testing.T) {
+			caa := setCounterCAA(7)
+			err := caa.UnmarshalText([]byte(test))
+			assert.True(t, errors.Is(err, ErrMalformedCAA), "%v", err)
+			assert.Equal(t, setCounterCAA(7), caa)
+		})
+	}
+}
+
+func Test_MarshalJSON_SlidingKeepsLastActive(t *testing.T) {
+	caa := &Sliding{Timeout: -1500000000, LastActive: 1500000020}
+
+	b, err := json.Marshal(caa)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Timeout":-1500000000,"LastActive":1500000020}`, string(b))
+
+	got := NewSliding(0)
+	assert.NoError(t, json.Unmarshal(b, got))
+	assert.Equal(t, caa, got)
+
+	_, ok := CAA(caa).(json.Marshaler)
+	assert.False(t, ok)
+}
+
+func Test_MarshalJSON_ClockedTimeoutKeepsLeeway(t *testing.T) {
+	caa := &ClockedTimeout{Timeout: 1500000000, Leeway: 5}
+
+	b, err := json.Marshal(caa)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"Timeout":1500000000,"Clock":null,"Leeway":5}`, string(b))
+
+	got := &ClockedTimeout{}
+	assert.NoError(t, json.Unmarshal(b, got))
+	assert.Equal(t, caa, got)
+}
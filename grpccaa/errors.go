@@ -0,0 +1,76 @@
+package grpccaa
+
+import (
+	"errors"
+
+	"github.com/endiangroup/compandauth/internal/authn"
+)
+
+// Code mirrors the values of grpc's codes.Code that the interceptors use.
+type Code uint32
+
+const (
+	OK               Code = 0
+	Unknown          Code = 2
+	PermissionDenied Code = 7
+	Internal         Code = 13
+	Unauthenticated  Code = 16
+)
+
+func (c Code) String() string {
+	switch c {
+	case OK:
+		return "OK"
+	case PermissionDenied:
+		return "PermissionDenied"
+	case Internal:
+		return "Internal"
+	case Unauthenticated:
+		return "Unauthenticated"
+	}
+
+	return "Unknown"
+}
+
+// Error is returned by the interceptors when rejecting a call, it wraps the
+// reason the call was rejected.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func newError(err error) *Error {
+	code := Internal
+
+	switch authn.Classify(err) {
+	case authn.Locked:
+		code = PermissionDenied
+	case authn.Unauthenticated:
+		code = Unauthenticated
+	}
+
+	return &Error{Code: code, Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Code.String() + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf returns the Code of err, OK if err is nil and Unknown if it isn't an
+// *Error.
+func CodeOf(err error) Code {
+	if err == nil {
+		return OK
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	return Unknown
+}
@@ -0,0 +1,28 @@
+package grpccaa
+
+import (
+	"strings"
+
+	"github.com/endiangroup/compandauth/token"
+)
+
+// Bearer returns a SessionExtractor decoding the token package token in the
+// "authorization: Bearer" metadata. Any token that fails to decode is treated
+// as no session at all.
+func Bearer(signer *token.Signer) SessionExtractor {
+	return func(md Metadata) (Session, error) {
+		const prefix = "Bearer "
+
+		header := md.Get("authorization")
+		if !strings.HasPrefix(header, prefix) {
+			return Session{}, ErrNoSession
+		}
+
+		t, err := signer.Decode(strings.TrimPrefix(header, prefix))
+		if err != nil {
+			return Session{}, ErrNoSession
+		}
+
+		return Session{Entity: t.Entity, CAA: t.CAA}, nil
+	}
+}
@@ -0,0 +1,188 @@
+// Package grpccaa provides unary and stream server interceptors that validate
+// sessions carried in request metadata against the CAA of the entity they
+// belong to.
+//
+// To avoid depending on gRPC the interceptors mirror the shapes of grpc's
+// UnaryServerInterceptor and StreamServerInterceptor, and read metadata put
+// in the context with NewIncomingContext. Adapting them to a gRPC server
+// takes a few lines: copy metadata.FromIncomingContext into NewIncomingContext
+// and convert errors with status.Error(codes.Code(CodeOf(err)), err.Error()).
+package grpccaa
+
+import (
+	"context"
+	"strings"
+
+	"github.com/endiangroup/compandauth/internal/authn"
+)
+
+var (
+	// ErrNoSession should be returned by a SessionExtractor when a request
+	// doesn't carry a session. It is the same error as httpcaa.ErrNoSession.
+	ErrNoSession = authn.ErrNoSession
+	// ErrUnknownEntity should be returned by an EntityLoader when the
+	// session's entity doesn't exist. It is the same error as
+	// httpcaa.ErrUnknownEntity.
+	ErrUnknownEntity = authn.ErrUnknownEntity
+)
+
+// Metadata mirrors grpc's metadata.MD, keys are lower case.
+type Metadata map[string][]string
+
+// Get returns the first value for key, or "" if there isn't one.
+func (md Metadata) Get(key string) string {
+	values := md[strings.ToLower(key)]
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+type contextKey int
+
+const (
+	metadataKey contextKey = iota
+	entityKey
+	sessionKey
+)
+
+// NewIncomingContext returns a copy of ctx carrying md for the interceptors.
+func NewIncomingContext(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey, md)
+}
+
+// FromIncomingContext returns the Metadata put in ctx by NewIncomingContext.
+func FromIncomingContext(ctx context.Context) (Metadata, bool) {
+	md, ok := ctx.Value(metadataKey).(Metadata)
+
+	return md, ok
+}
+
+// Session is the part of an incoming session needed to validate it.
+type Session = authn.Session
+
+// SessionExtractor extracts a Session from request metadata, it must only
+// return sessions that have been verified as untampered (e.g. by checking a
+// signature). Return ErrNoSession if there isn't one.
+type SessionExtractor func(Metadata) (Session, error)
+
+// EntityLoader fetches the entity a session belongs to and its CAA. Return
+// ErrUnknownEntity if there isn't one, an entity without a CAA is treated as
+// an internal error.
+type EntityLoader = authn.EntityLoader
+
+// DeltaFunc returns the delta (number of sessions for a Counter, duration in
+// seconds for a Timeout) to validate an entity's sessions with.
+type DeltaFunc = authn.DeltaFunc
+
+// Delta returns a DeltaFunc using the same delta for every entity.
+func Delta(n int64) DeltaFunc {
+	return authn.Delta(n)
+}
+
+// Mirrors grpc.UnaryServerInfo.
+type UnaryServerInfo struct {
+	FullMethod string
+}
+
+// Mirrors grpc.UnaryHandler.
+type UnaryHandler func(ctx context.Context, req interface{}) (interface{}, error)
+
+// Mirrors grpc.UnaryServerInterceptor.
+type UnaryServerInterceptor func(ctx context.Context, req interface{}, info *UnaryServerInfo, handler UnaryHandler) (interface{}, error)
+
+// Mirrors the parts of grpc.ServerStream the interceptor needs.
+type ServerStream interface {
+	Context() context.Context
+}
+
+// Mirrors grpc.StreamServerInfo.
+type StreamServerInfo struct {
+	FullMethod     string
+	IsClientStream bool
+	IsServerStream bool
+}
+
+// Mirrors grpc.StreamHandler.
+type StreamHandler func(srv interface{}, stream ServerStream) error
+
+// Mirrors grpc.StreamServerInterceptor.
+type StreamServerInterceptor func(srv interface{}, stream ServerStream, info *StreamServerInfo, handler StreamHandler) error
+
+// UnaryInterceptor rejects calls whose session isn't valid for its entity's
+// CAA, see Authenticate. Valid calls are handled with the entity and session
+// in their context, see EntityFromContext and SessionFromContext.
+func UnaryInterceptor(loader EntityLoader, extractor SessionExtractor, delta DeltaFunc) UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *UnaryServerInfo, handler UnaryHandler) (interface{}, error) {
+		ctx, err := Authenticate(ctx, loader, extractor, delta)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor rejects streams whose session isn't valid for its
+// entity's CAA, see Authenticate. Valid streams are handled with the entity
+// and session in their context, see EntityFromContext and
+// SessionFromContext.
+func StreamInterceptor(loader EntityLoader, extractor SessionExtractor, delta DeltaFunc) StreamServerInterceptor {
+	return func(srv interface{}, stream ServerStream, info *StreamServerInfo, handler StreamHandler) error {
+		ctx, err := Authenticate(stream.Context(), loader, extractor, delta)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+type authenticatedStream struct {
+	ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// Authenticate validates the session in ctx's incoming metadata against its
+// entity's CAA, returning a copy of ctx carrying the entity and session. The
+// returned error is an *Error with code PermissionDenied if the CAA is
+// locked, Unauthenticated if the session is missing or invalid and Internal
+// if the session or entity couldn't be loaded for any other reason.
+func Authenticate(ctx context.Context, loader EntityLoader, extractor SessionExtractor, delta DeltaFunc) (context.Context, error) {
+	md, _ := FromIncomingContext(ctx)
+
+	session, err := extractor(md)
+	if err != nil {
+		return nil, newError(err)
+	}
+
+	entity, err := authn.Authenticate(ctx, loader, delta, session)
+	if err != nil {
+		return nil, newError(err)
+	}
+
+	ctx = context.WithValue(ctx, entityKey, entity)
+	ctx = context.WithValue(ctx, sessionKey, session)
+
+	return ctx, nil
+}
+
+// EntityFromContext returns the entity the call's session was validated
+// against.
+func EntityFromContext(ctx context.Context) (interface{}, bool) {
+	entity := ctx.Value(entityKey)
+
+	return entity, entity != nil
+}
+
+// SessionFromContext returns the validated Session of the call.
+func SessionFromContext(ctx context.Context) (Session, bool) {
+	session, ok := ctx.Value(sessionKey).(Session)
+
+	return session, ok
+}
@@ -0,0 +1,196 @@
+package grpccaa
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/endiangroup/compandauth"
+	"github.com/endiangroup/compandauth/token"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	ID  string
+	CAA *compandauth.Counter
+}
+
+type stream struct {
+	ctx context.Context
+}
+
+func (s stream) Context() context.Context { return s.ctx }
+
+func newSigner(t *testing.T) *token.Signer {
+	signer, err := token.NewSigner("k1", []byte("0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, err)
+
+	return signer
+}
+
+func loader(users map[string]*user) EntityLoader {
+	return func(ctx context.Context, id string) (interface{}, compandauth.CAA, error) {
+		u, ok := users[id]
+		if !ok {
+			return nil, nil, ErrUnknownEntity
+		}
+
+		return u, u.CAA, nil
+	}
+}
+
+func incomingContext(t *testing.T, signer *token.Signer, entity string, s compandauth.SessionCAA) context.Context {
+	encoded, err := signer.Encode(token.Token{Entity: entity, CAA: s})
+	assert.NoError(t, err)
+
+	return NewIncomingContext(context.Background(), Metadata{"authorization": {"Bearer " + encoded}})
+}
+
+func Test_UnaryInterceptor_ReturnsCodeForSession(t *testing.T) {
+	signer := newSigner(t)
+	alice := &user{ID: "alice", CAA: compandauth.NewCounter()}
+	revoked := alice.CAA.Issue()
+	valid := alice.CAA.Issue()
+	locked := &user{ID: "locked", CAA: compandauth.NewCounter()}
+	lockedSession := locked.CAA.Issue()
+	locked.CAA.Lock()
+	users := map[string]*user{"alice": alice, "locked": locked}
+
+	tests := map[string]struct {
+		Ctx          context.Context
+		ExpectedCode Code
+		ExpectedErr  error
+	}{
+		"valid":          {Ctx: incomingContext(t, signer, "alice", valid), ExpectedCode: OK},
+		"revoked":        {Ctx: incomingContext(t, signer, "alice", revoked), ExpectedCode: Unauthenticated, ExpectedErr: compandauth.ErrRevoked},
+		"locked":         {Ctx: incomingContext(t, signer, "locked", lockedSession), ExpectedCode: PermissionDenied, ExpectedErr: compandauth.ErrLocked},
+		"unknown entity": {Ctx: incomingContext(t, signer, "bob", 0), ExpectedCode: Unauthenticated, ExpectedErr: ErrUnknownEntity},
+		"no metadata":    {Ctx: context.Background(), ExpectedCode: Unauthenticated, ExpectedErr: ErrNoSession},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			interceptor := UnaryInterceptor(loader(users), Bearer(signer), Delta(1))
+
+			resp, err := interceptor(test.Ctx, "req", &UnaryServerInfo{FullMethod: "/svc/Method"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "resp", nil
+			})
+
+			assert.Equal(t, test.ExpectedCode, CodeOf(err))
+			if test.ExpectedErr != nil {
+				assert.True(t, errors.Is(err, test.ExpectedErr))
+				assert.Nil(t, resp)
+			} else {
+				assert.Equal(t, "resp", resp)
+			}
+		})
+	}
+}
+
+func Test_UnaryInterceptor_PutsEntityAndSessionInContext(t *testing.T) {
+	signer := newSigner(t)
+	alice := &user{ID: "alice", CAA: compandauth.NewCounter()}
+	s := alice.CAA.Issue()
+	interceptor := UnaryInterceptor(loader(map[string]*user{"alice": alice}), Bearer(signer), Delta(1))
+
+	_, err := interceptor(incomingContext(t, signer, "alice", s), nil, &UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		entity, ok := EntityFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, alice, entity)
+
+		session, ok := SessionFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, Session{Entity: "alice", CAA: s}, session)
+
+		return nil, nil
+	})
+
+	assert.NoError(t, err)
+}
+
+func Test_UnaryInterceptor_ReturnsInternalWhenLoaderFails(t *testing.T) {
+	signer := newSigner(t)
+	failing := func(ctx context.Context, id string) (interface{}, compandauth.CAA, error) {
+		return nil, nil, errors.New("database unavailable")
+	}
+
+	_, err := UnaryInterceptor(failing, Bearer(signer), Delta(1))(incomingContext(t, signer, "alice", 0), nil, &UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		t.Fatal("handler should not be called")
+		return nil, nil
+	})
+
+	assert.Equal(t, Internal, CodeOf(err))
+}
+
+func Test_StreamInterceptor_WrapsStreamContext(t *testing.T) {
+	signer := newSigner(t)
+	alice := &user{ID: "alice", CAA: compandauth.NewCounter()}
+	s := alice.CAA.Issue()
+	interceptor := StreamInterceptor(loader(map[string]*user{"alice": alice}), Bearer(signer), Delta(1))
+
+	called := false
+	err := interceptor(nil, stream{incomingContext(t, signer, "alice", s)}, &StreamServerInfo{IsServerStream: true}, func(srv interface{}, ss ServerStream) error {
+		called = true
+		entity, _ := EntityFromContext(ss.Context())
+		assert.Equal(t, alice, entity)
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
+
+func Test_StreamInterceptor_RejectsLockedCAA(t *testing.T) {
+	signer := newSigner(t)
+	alice := &user{ID: "alice", CAA: compandauth.NewCounter()}
+	s := alice.CAA.Issue()
+	alice.CAA.Lock()
+	interceptor := StreamInterceptor(loader(map[string]*user{"alice": alice}), Bearer(signer), Delta(1))
+
+	err := interceptor(nil, stream{incomingContext(t, signer, "alice", s)}, &StreamServerInfo{}, func(srv interface{}, ss ServerStream) error {
+		t.Fatal("handler should not be called")
+		return nil
+	})
+
+	assert.Equal(t, PermissionDenied, CodeOf(err))
+}
+
+func Test_Authenticate_ReturnsInternalWhenLoaderReturnsNoCAA(t *testing.T) {
+	signer := newSigner(t)
+	noCAA := func(ctx context.Context, id string) (interface{}, compandauth.CAA, error) {
+		return id, nil, nil
+	}
+
+	_, err := Authenticate(incomingContext(t, signer, "alice", 0), noCAA, Bearer(signer), Delta(1))
+
+	assert.Equal(t, Internal, CodeOf(err))
+}
+
+func Test_Authenticate_ReturnsUnauthenticatedForReusedRefreshSessions(t *testing.T) {
+	signer := newSigner(t)
+	family := compandauth.NewRefreshFamily(compandauth.ReuseLock)
+	s := family.Issue()
+	_, err := family.Refresh(s)
+	assert.NoError(t, err)
+	loader := func(ctx context.Context, id string) (interface{}, compandauth.CAA, error) {
+		return id, family, nil
+	}
+
+	_, err = Authenticate(incomingContext(t, signer, "alice", s), loader, Bearer(signer), Delta(1))
+
+	assert.Equal(t, Unauthenticated, CodeOf(err))
+	assert.True(t, errors.Is(err, compandauth.ErrReuseDetected))
+}
+
+func Test_CodeOf_HandlesForeignErrors(t *testing.T) {
+	assert.Equal(t, OK, CodeOf(nil))
+	assert.Equal(t, Unknown, CodeOf(errors.New("other")))
+}
+
+func Test_Metadata_GetIsCaseInsensitive(t *testing.T) {
+	md := Metadata{"authorization": {"a", "b"}}
+
+	assert.Equal(t, "a", md.Get("Authorization"))
+	assert.Equal(t, "", md.Get("missing"))
+}
@@ -0,0 +1,46 @@
+package httpcaa
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/endiangroup/compandauth/token"
+)
+
+// Cookie returns a SessionExtractor decoding the token package token stored
+// in the named cookie.
+func Cookie(name string, signer *token.Signer) SessionExtractor {
+	return func(r *http.Request) (Session, error) {
+		cookie, err := r.Cookie(name)
+		if err != nil {
+			return Session{}, ErrNoSession
+		}
+
+		return decode(signer, cookie.Value)
+	}
+}
+
+// Bearer returns a SessionExtractor decoding the token package token in the
+// request's "Authorization: Bearer" header.
+func Bearer(signer *token.Signer) SessionExtractor {
+	return func(r *http.Request) (Session, error) {
+		const prefix = "Bearer "
+
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, prefix) {
+			return Session{}, ErrNoSession
+		}
+
+		return decode(signer, strings.TrimPrefix(header, prefix))
+	}
+}
+
+// Any token that fails to decode is treated as no session at all.
+func decode(signer *token.Signer, encoded string) (Session, error) {
+	t, err := signer.Decode(encoded)
+	if err != nil {
+		return Session{}, ErrNoSession
+	}
+
+	return Session{Entity: t.Entity, CAA: t.CAA}, nil
+}
@@ -0,0 +1,110 @@
+// Package httpcaa provides net/http middleware that validates incoming
+// sessions against the CAA of the entity they belong to.
+package httpcaa
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/endiangroup/compandauth/internal/authn"
+)
+
+var (
+	// ErrNoSession should be returned by a SessionExtractor when a request
+	// doesn't carry a session. It is the same error as grpccaa.ErrNoSession.
+	ErrNoSession = authn.ErrNoSession
+	// ErrUnknownEntity should be returned by an EntityLoader when the
+	// session's entity doesn't exist. It is the same error as
+	// grpccaa.ErrUnknownEntity.
+	ErrUnknownEntity = authn.ErrUnknownEntity
+)
+
+// Session is the part of an incoming session needed to validate it.
+type Session = authn.Session
+
+// SessionExtractor extracts a Session from a request, it must only return
+// sessions that have been verified as untampered (e.g. by checking a
+// signature). Return ErrNoSession if there isn't one.
+type SessionExtractor func(*http.Request) (Session, error)
+
+// EntityLoader fetches the entity a session belongs to and its CAA. Return
+// ErrUnknownEntity if there isn't one, an entity without a CAA is treated as
+// an internal error.
+type EntityLoader = authn.EntityLoader
+
+// DeltaFunc returns the delta (number of sessions for a Counter, duration in
+// seconds for a Timeout) to validate an entity's sessions with.
+type DeltaFunc = authn.DeltaFunc
+
+// Delta returns a DeltaFunc using the same delta for every entity.
+func Delta(n int64) DeltaFunc {
+	return authn.Delta(n)
+}
+
+type contextKey int
+
+const (
+	entityKey contextKey = iota
+	sessionKey
+)
+
+// Middleware rejects requests whose session isn't valid for its entity's CAA,
+// responding with 423 Locked if the CAA is locked, 401 Unauthorized if the
+// session is missing or invalid and 500 Internal Server Error if the session
+// or entity couldn't be loaded for any other reason. Valid requests are
+// passed on with the entity and session in their context, see EntityFromContext
+// and SessionFromContext.
+func Middleware(loader EntityLoader, extractor SessionExtractor, delta DeltaFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session, err := extractor(r)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			entity, err := authn.Authenticate(r.Context(), loader, delta, session)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), entityKey, entity)
+			ctx = context.WithValue(ctx, sessionKey, session)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// StatusCode returns the status code Middleware responds with for err.
+func StatusCode(err error) int {
+	switch authn.Classify(err) {
+	case authn.Locked:
+		return http.StatusLocked
+	case authn.Unauthenticated:
+		return http.StatusUnauthorized
+	}
+
+	return http.StatusInternalServerError
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	code := StatusCode(err)
+	http.Error(w, http.StatusText(code), code)
+}
+
+// EntityFromContext returns the entity Middleware validated the request's
+// session against.
+func EntityFromContext(ctx context.Context) (interface{}, bool) {
+	entity := ctx.Value(entityKey)
+
+	return entity, entity != nil
+}
+
+// SessionFromContext returns the Session Middleware validated.
+func SessionFromContext(ctx context.Context) (Session, bool) {
+	session, ok := ctx.Value(sessionKey).(Session)
+
+	return session, ok
+}
@@ -0,0 +1,161 @@
+package httpcaa
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/endiangroup/compandauth"
+	"github.com/endiangroup/compandauth/token"
+	"github.com/stretchr/testify/assert"
+)
+
+type user struct {
+	ID                string
+	MaxActiveSessions int64
+	CAA               *compandauth.Counter
+}
+
+func newSigner(t *testing.T) *token.Signer {
+	signer, err := token.NewSigner("k1", []byte("0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, err)
+
+	return signer
+}
+
+func loader(users map[string]*user) EntityLoader {
+	return func(ctx context.Context, id string) (interface{}, compandauth.CAA, error) {
+		u, ok := users[id]
+		if !ok {
+			return nil, nil, ErrUnknownEntity
+		}
+
+		return u, u.CAA, nil
+	}
+}
+
+func maxActiveSessions(entity interface{}) int64 {
+	return entity.(*user).MaxActiveSessions
+}
+
+func bearerRequest(t *testing.T, signer *token.Signer, entity string, s compandauth.SessionCAA) *http.Request {
+	encoded, err := signer.Encode(token.Token{Entity: entity, CAA: s})
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+encoded)
+
+	return r
+}
+
+func Test_Middleware_RespondsWithStatusForSession(t *testing.T) {
+	signer := newSigner(t)
+	alice := &user{ID: "alice", MaxActiveSessions: 1, CAA: compandauth.NewCounter()}
+	revoked := alice.CAA.Issue()
+	valid := alice.CAA.Issue()
+	locked := &user{ID: "locked", MaxActiveSessions: 1, CAA: compandauth.NewCounter()}
+	lockedSession := locked.CAA.Issue()
+	locked.CAA.Lock()
+	users := map[string]*user{"alice": alice, "locked": locked}
+
+	tests := map[string]struct {
+		Request        *http.Request
+		ExpectedStatus int
+	}{
+		"valid":          {Request: bearerRequest(t, signer, "alice", valid), ExpectedStatus: http.StatusOK},
+		"revoked":        {Request: bearerRequest(t, signer, "alice", revoked), ExpectedStatus: http.StatusUnauthorized},
+		"locked":         {Request: bearerRequest(t, signer, "locked", lockedSession), ExpectedStatus: http.StatusLocked},
+		"unknown entity": {Request: bearerRequest(t, signer, "bob", 0), ExpectedStatus: http.StatusUnauthorized},
+		"no session":     {Request: httptest.NewRequest("GET", "/", nil), ExpectedStatus: http.StatusUnauthorized},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			handler := Middleware(loader(users), Bearer(signer), maxActiveSessions)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, test.Request)
+
+			assert.Equal(t, test.ExpectedStatus, w.Code)
+		})
+	}
+}
+
+func Test_Middleware_PutsEntityAndSessionInContext(t *testing.T) {
+	signer := newSigner(t)
+	alice := &user{ID: "alice", CAA: compandauth.NewCounter()}
+	s := alice.CAA.Issue()
+
+	var entity interface{}
+	var session Session
+	handler := Middleware(loader(map[string]*user{"alice": alice}), Bearer(signer), Delta(1))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entity, _ = EntityFromContext(r.Context())
+		session, _ = SessionFromContext(r.Context())
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), bearerRequest(t, signer, "alice", s))
+
+	assert.Equal(t, alice, entity)
+	assert.Equal(t, Session{Entity: "alice", CAA: s}, session)
+}
+
+func Test_Middleware_RespondsWithInternalServerErrorWhenLoaderFails(t *testing.T) {
+	signer := newSigner(t)
+	failing := func(ctx context.Context, id string) (interface{}, compandauth.CAA, error) {
+		return nil, nil, errors.New("database unavailable")
+	}
+	handler := Middleware(failing, Bearer(signer), Delta(1))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler should not be called")
+	}))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, bearerRequest(t, signer, "alice", 0))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func Test_Middleware_RespondsWithInternalServerErrorWhenLoaderReturnsNoCAA(t *testing.T) {
+	signer := newSigner(t)
+	noCAA := func(ctx context.Context, id string) (interface{}, compandauth.CAA, error) {
+		return id, nil, nil
+	}
+	handler := Middleware(noCAA, Bearer(signer), Delta(1))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler should not be called")
+	}))
+	w := httptest.NewRecorder()
+
+	handler.ServeHTTP(w, bearerRequest(t, signer, "alice", 0))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func Test_StatusCode_RespondsUnauthorizedToReusedRefreshSessions(t *testing.T) {
+	assert.Equal(t, http.StatusUnauthorized, StatusCode(compandauth.ErrReuseDetected))
+}
+
+func Test_Cookie_ExtractsSessionFromToken(t *testing.T) {
+	signer := newSigner(t)
+	encoded, err := signer.Encode(token.Token{Entity: "alice", CAA: 3})
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: encoded})
+
+	session, err := Cookie("session", signer)(r)
+	assert.NoError(t, err)
+	assert.Equal(t, Session{Entity: "alice", CAA: 3}, session)
+
+	_, err = Cookie("other", signer)(r)
+	assert.Equal(t, ErrNoSession, err)
+}
+
+func Test_Bearer_TreatsUndecodableTokensAsNoSession(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer v1.k1.abc.def")
+
+	_, err := Bearer(newSigner(t))(r)
+
+	assert.Equal(t, ErrNoSession, err)
+}
@@ -0,0 +1,95 @@
+// Package authn holds what the httpcaa and grpccaa adapters share: the
+// session and entity loading types, and how the errors of validating a
+// session are classified into their responses.
+package authn
+
+import (
+	"context"
+	"errors"
+
+	"github.com/endiangroup/compandauth"
+)
+
+var (
+	// ErrNoSession should be returned by a session extractor when a request
+	// doesn't carry a session.
+	ErrNoSession = errors.New("compandauth: no session")
+	// ErrUnknownEntity should be returned by an EntityLoader when the
+	// session's entity doesn't exist.
+	ErrUnknownEntity = errors.New("compandauth: unknown entity")
+	// ErrNoCAA is returned by Authenticate when an EntityLoader returns an
+	// entity without a CAA, which is a bug in the loader so is Internal.
+	ErrNoCAA = errors.New("compandauth: entity loader returned no CAA")
+)
+
+// Session is the part of an incoming session needed to validate it.
+type Session struct {
+	Entity string
+	CAA    compandauth.SessionCAA
+}
+
+// EntityLoader fetches the entity a session belongs to and its CAA. Return
+// ErrUnknownEntity if there isn't one, an entity without a CAA is treated as
+// an internal error.
+type EntityLoader func(ctx context.Context, entityID string) (entity interface{}, caa compandauth.CAA, err error)
+
+// DeltaFunc returns the delta (number of sessions for a Counter, duration in
+// seconds for a Timeout) to validate an entity's sessions with.
+type DeltaFunc func(entity interface{}) int64
+
+// Delta returns a DeltaFunc using the same delta for every entity.
+func Delta(n int64) DeltaFunc {
+	return func(interface{}) int64 {
+		return n
+	}
+}
+
+// Class is how a request failing authentication should be answered.
+type Class int
+
+const (
+	// The session or entity couldn't be loaded, e.g. the store is down.
+	Internal Class = iota
+	// The session is missing or isn't valid for its entity's CAA.
+	Unauthenticated
+	// The entity's CAA is locked.
+	Locked
+)
+
+// Classify returns how a request failing authentication with err should be
+// answered. Errors it doesn't recognise are Internal.
+func Classify(err error) Class {
+	switch {
+	case errors.Is(err, compandauth.ErrLocked):
+		return Locked
+	case errors.Is(err, ErrNoSession),
+		errors.Is(err, ErrUnknownEntity),
+		errors.Is(err, compandauth.ErrNeverIssued),
+		errors.Is(err, compandauth.ErrRevoked),
+		errors.Is(err, compandauth.ErrExpired),
+		errors.Is(err, compandauth.ErrFutureSession),
+		errors.Is(err, compandauth.ErrReuseDetected):
+		return Unauthenticated
+	}
+
+	return Internal
+}
+
+// Authenticate loads session's entity and validates session against its CAA,
+// returning the entity. The error is one of the loader's, the CAA's or
+// ErrNoCAA, see Classify.
+func Authenticate(ctx context.Context, loader EntityLoader, delta DeltaFunc, session Session) (interface{}, error) {
+	entity, caa, err := loader(ctx, session.Entity)
+	if err != nil {
+		return nil, err
+	}
+	if caa == nil {
+		return nil, ErrNoCAA
+	}
+
+	if err := caa.Validate(session.CAA, delta(entity)); err != nil {
+		return nil, err
+	}
+
+	return entity, nil
+}
@@ -0,0 +1,71 @@
+package authn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/endiangroup/compandauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Classify_ReturnsClassOfErr(t *testing.T) {
+	tests := []struct {
+		Err           error
+		ExpectedClass Class
+	}{
+		{Err: compandauth.ErrLocked, ExpectedClass: Locked},
+		{Err: ErrNoSession, ExpectedClass: Unauthenticated},
+		{Err: ErrUnknownEntity, ExpectedClass: Unauthenticated},
+		{Err: compandauth.ErrNeverIssued, ExpectedClass: Unauthenticated},
+		{Err: compandauth.ErrRevoked, ExpectedClass: Unauthenticated},
+		{Err: compandauth.ErrExpired, ExpectedClass: Unauthenticated},
+		{Err: compandauth.ErrFutureSession, ExpectedClass: Unauthenticated},
+		{Err: compandauth.ErrReuseDetected, ExpectedClass: Unauthenticated},
+		{Err: fmt.Errorf("loading: %w", ErrUnknownEntity), ExpectedClass: Unauthenticated},
+		{Err: errors.New("database unavailable"), ExpectedClass: Internal},
+		{Err: ErrNoCAA, ExpectedClass: Internal},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Err.Error(), func(t *testing.T) {
+			assert.Equal(t, test.ExpectedClass, Classify(test.Err))
+		})
+	}
+}
+
+func Test_Authenticate_ReturnsEntityOfValidSession(t *testing.T) {
+	caa := compandauth.NewCounter()
+	revoked := caa.Issue()
+	valid := caa.Issue()
+	loader := func(ctx context.Context, id string) (interface{}, compandauth.CAA, error) {
+		if id != "alice" {
+			return nil, nil, ErrUnknownEntity
+		}
+
+		return "alice", caa, nil
+	}
+
+	entity, err := Authenticate(context.Background(), loader, Delta(1), Session{Entity: "alice", CAA: valid})
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", entity)
+
+	_, err = Authenticate(context.Background(), loader, Delta(1), Session{Entity: "alice", CAA: revoked})
+	assert.Equal(t, compandauth.ErrRevoked, err)
+
+	_, err = Authenticate(context.Background(), loader, Delta(1), Session{Entity: "bob"})
+	assert.Equal(t, ErrUnknownEntity, err)
+}
+
+func Test_Authenticate_ReturnsErrNoCAAIfLoaderReturnsNoCAA(t *testing.T) {
+	loader := func(ctx context.Context, id string) (interface{}, compandauth.CAA, error) {
+		return id, nil, nil
+	}
+
+	entity, err := Authenticate(context.Background(), loader, Delta(1), Session{Entity: "alice"})
+
+	assert.Nil(t, entity)
+	assert.Equal(t, ErrNoCAA, err)
+	assert.Equal(t, Internal, Classify(err))
+}
@@ -0,0 +1,85 @@
+package compandauth
+
+import "time"
+
+// CounterCAA is implemented by CAAs whose delta is a number of sessions.
+type CounterCAA interface {
+	CAA
+	countsSessions()
+}
+
+// TimeoutCAA is implemented by CAAs whose delta is a duration, measured in
+// units of durationUnit.
+type TimeoutCAA interface {
+	CAA
+	durationUnit() time.Duration
+}
+
+var (
+	_ = CounterCAA(NewCounter())
+	_ = CounterCAA(NewHybrid(0))
+	_ = TimeoutCAA(NewTimeout())
+	_ = TimeoutCAA(NewTimeoutMillis())
+)
+
+func (Counter) countsSessions()        {}
+func (*AtomicCounter) countsSessions() {}
+func (*BitmapCounter) countsSessions() {}
+func (*Slots) countsSessions()         {}
+func (*Hybrid) countsSessions()        {}
+
+func (Timeout) durationUnit() time.Duration         { return time.Second }
+func (*AtomicTimeout) durationUnit() time.Duration  { return time.Second }
+func (*Sliding) durationUnit() time.Duration        { return time.Second }
+func (*ClockedTimeout) durationUnit() time.Duration { return time.Second }
+func (TimeoutMillis) durationUnit() time.Duration   { return time.Millisecond }
+
+// CounterPolicy is how many of the most recently issued sessions of a
+// CounterCAA are valid.
+type CounterPolicy struct {
+	MaxSessions int64
+}
+
+// Binds the policy to caa. Only CounterCAAs can be bound, so binding a
+// CounterPolicy to a Timeout won't compile.
+func (p CounterPolicy) Bind(caa CounterCAA) Validator {
+	return Validator{caa: caa, delta: p.MaxSessions}
+}
+
+// TimeoutPolicy is how long sessions of a TimeoutCAA are valid for after
+// being issued. TTL is truncated to the precision of the CAA it is bound to.
+type TimeoutPolicy struct {
+	TTL time.Duration
+}
+
+// Binds the policy to caa, converting TTL to the CAA's precision. Only
+// TimeoutCAAs can be bound, so binding a TimeoutPolicy to a Counter won't
+// compile.
+func (p TimeoutPolicy) Bind(caa TimeoutCAA) Validator {
+	return Validator{caa: caa, delta: int64(p.TTL / caa.durationUnit())}
+}
+
+// Validator validates session CAAs against a CAA using the policy it was
+// bound with, see CounterPolicy.Bind and TimeoutPolicy.Bind.
+type Validator struct {
+	caa   CAA
+	delta int64
+}
+
+// Returns the bound CAA.
+func (v Validator) CAA() CAA {
+	return v.caa
+}
+
+// Returns the delta the policy was converted to.
+func (v Validator) Delta() int64 {
+	return v.delta
+}
+
+func (v Validator) IsValid(s SessionCAA) bool {
+	return v.caa.IsValid(s, v.delta)
+}
+
+func (v Validator) Validate(s SessionCAA) error {
+	return v.caa.Validate(s, v.delta)
+}
@@ -0,0 +1,51 @@
+package compandauth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CounterPolicy_ValidatesUsingMaxSessions(t *testing.T) {
+	caa := NewCounter()
+	first := caa.Issue()
+	second := caa.Issue()
+
+	v := CounterPolicy{MaxSessions: 1}.Bind(caa)
+
+	assert.Equal(t, int64(1), v.Delta())
+	assert.Equal(t, ErrRevoked, v.Validate(first))
+	assert.True(t, v.IsValid(second))
+	assert.Equal(t, CAA(caa), v.CAA())
+}
+
+func Test_TimeoutPolicy_ConvertsTTLToPrecisionOfCAA(t *testing.T) {
+	tests := []struct {
+		CAA           TimeoutCAA
+		TTL           time.Duration
+		ExpectedDelta int64
+	}{
+		{CAA: NewTimeout(), TTL: 5 * time.Minute, ExpectedDelta: 300},
+		{CAA: NewTimeout(), TTL: 1500 * time.Millisecond, ExpectedDelta: 1},
+		{CAA: NewAtomicTimeout(), TTL: time.Minute, ExpectedDelta: 60},
+		{CAA: NewSliding(0), TTL: time.Minute, ExpectedDelta: 60},
+		{CAA: NewTimeoutMillis(), TTL: 1500 * time.Millisecond, ExpectedDelta: 1500},
+		{CAA: NewTimeoutWithClock(nil), TTL: time.Minute, ExpectedDelta: 60},
+	}
+
+	for _, test := range tests {
+		t.Run(test.TTL.String(), func(t *testing.T) {
+			assert.Equal(t, test.ExpectedDelta, TimeoutPolicy{TTL: test.TTL}.Bind(test.CAA).Delta())
+		})
+	}
+}
+
+func Test_TimeoutPolicy_ValidatesUsingTTL(t *testing.T) {
+	at := time.Now()
+	caa := NewTimeout()
+	s := caa.IssueAt(at.Add(-10 * time.Second))
+
+	assert.NoError(t, TimeoutPolicy{TTL: time.Minute}.Bind(caa).Validate(s))
+	assert.Equal(t, ErrExpired, TimeoutPolicy{TTL: 5 * time.Second}.Bind(caa).Validate(s))
+}
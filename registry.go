@@ -0,0 +1,184 @@
+package compandauth
+
+import "sync"
+
+// Registry is an in-process store of CAAs keyed by entity (e.g. user ID),
+// spread across a number of independently locked shards to reduce
+// contention. A Registry holds either Counters or Timeouts, see
+// NewCounterRegistry and NewTimeoutRegistry.
+type Registry struct {
+	caa    func(*int64) CAA
+	shards []registryShard
+}
+
+type registryShard struct {
+	mu   sync.RWMutex
+	caas map[string]int64
+}
+
+// Returns a Registry of Counters spread over n shards.
+func NewCounterRegistry(n int) *Registry {
+	return newRegistry(n, func(v *int64) CAA { return (*Counter)(v) })
+}
+
+// Returns a Registry of Timeouts spread over n shards.
+func NewTimeoutRegistry(n int) *Registry {
+	return newRegistry(n, func(v *int64) CAA { return (*Timeout)(v) })
+}
+
+func newRegistry(n int, caa func(*int64) CAA) *Registry {
+	if n < 1 {
+		n = 1
+	}
+
+	r := &Registry{
+		caa:    caa,
+		shards: make([]registryShard, n),
+	}
+
+	for i := range r.shards {
+		r.shards[i].caas = map[string]int64{}
+	}
+
+	return r
+}
+
+// Issues the next session CAA for key, adding key to the Registry if it isn't
+// already present.
+func (r *Registry) Issue(key string) SessionCAA {
+	shard := r.shard(key)
+	shard.mu.Lock()
+	v := shard.caas[key]
+	sessionCAA := r.caa(&v).Issue()
+	shard.caas[key] = v
+	shard.mu.Unlock()
+
+	return sessionCAA
+}
+
+// Validates s against the CAA for key, see Counter.Validate and
+// Timeout.Validate. Returns ErrNeverIssued if key isn't present.
+func (r *Registry) Validate(key string, s SessionCAA, n int64) error {
+	shard := r.shard(key)
+	shard.mu.RLock()
+	v := shard.caas[key]
+	shard.mu.RUnlock()
+
+	return r.caa(&v).Validate(s, n)
+}
+
+func (r *Registry) IsValid(key string, s SessionCAA, n int64) bool {
+	return r.Validate(key, s, n) == nil
+}
+
+// Revokes sessions for key, see Counter.Revoke and Timeout.Revoke. Has no
+// effect if key isn't present.
+func (r *Registry) Revoke(key string, n int64) {
+	r.update(key, func(caa CAA) { caa.Revoke(n) })
+}
+
+// Locks the CAA for key. Has no effect if key isn't present.
+func (r *Registry) Lock(key string) {
+	r.update(key, CAA.Lock)
+}
+
+// Unlocks the CAA for key. Has no effect if key isn't present.
+func (r *Registry) Unlock(key string) {
+	r.update(key, CAA.Unlock)
+}
+
+func (r *Registry) IsLocked(key string) bool {
+	shard := r.shard(key)
+	shard.mu.RLock()
+	v := shard.caas[key]
+	shard.mu.RUnlock()
+
+	return r.caa(&v).IsLocked()
+}
+
+// Removes key from the Registry.
+func (r *Registry) Delete(key string) {
+	shard := r.shard(key)
+	shard.mu.Lock()
+	delete(shard.caas, key)
+	shard.mu.Unlock()
+}
+
+// Returns the number of keys in the Registry.
+func (r *Registry) Len() int {
+	n := 0
+	for i := range r.shards {
+		shard := &r.shards[i]
+		shard.mu.RLock()
+		n += len(shard.caas)
+		shard.mu.RUnlock()
+	}
+
+	return n
+}
+
+// Calls fn with a copy of the CAA for each key in the Registry, stopping if fn
+// returns false. Each shard is read locked whilst it is being iterated, so fn
+// must not modify the Registry. Keys are not visited in any defined order.
+func (r *Registry) Range(fn func(key string, caa CAA) bool) {
+	for i := range r.shards {
+		shard := &r.shards[i]
+		shard.mu.RLock()
+		for key, v := range shard.caas {
+			v := v
+			if !fn(key, r.caa(&v)) {
+				shard.mu.RUnlock()
+				return
+			}
+		}
+		shard.mu.RUnlock()
+	}
+}
+
+// Returns the raw CAA value of every key in the Registry, e.g. for persisting.
+// Each shard is copied atomically but the Registry as a whole is not.
+func (r *Registry) Snapshot() map[string]int64 {
+	snapshot := map[string]int64{}
+	for i := range r.shards {
+		shard := &r.shards[i]
+		shard.mu.RLock()
+		for key, v := range shard.caas {
+			snapshot[key] = v
+		}
+		shard.mu.RUnlock()
+	}
+
+	return snapshot
+}
+
+// Adds or replaces the raw CAA values in snapshot, e.g. when restoring from a
+// previous Snapshot.
+func (r *Registry) Restore(snapshot map[string]int64) {
+	for key, v := range snapshot {
+		shard := r.shard(key)
+		shard.mu.Lock()
+		shard.caas[key] = v
+		shard.mu.Unlock()
+	}
+}
+
+func (r *Registry) update(key string, fn func(CAA)) {
+	shard := r.shard(key)
+	shard.mu.Lock()
+	if v, ok := shard.caas[key]; ok {
+		fn(r.caa(&v))
+		shard.caas[key] = v
+	}
+	shard.mu.Unlock()
+}
+
+// FNV-1a, inlined to avoid allocating a hash.Hash per lookup.
+func (r *Registry) shard(key string) *registryShard {
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+
+	return &r.shards[h%uint32(len(r.shards))]
+}
@@ -0,0 +1,133 @@
+package compandauth
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/endiangroup/compandauth/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Registry_IssuesAndValidatesPerKey(t *testing.T) {
+	r := NewCounterRegistry(4)
+
+	alice := r.Issue("alice")
+	r.Issue("alice")
+	bob := r.Issue("bob")
+
+	assert.Equal(t, SessionCAA(0), alice)
+	assert.Equal(t, SessionCAA(0), bob)
+	assert.Equal(t, ErrRevoked, r.Validate("alice", alice, 1))
+	assert.True(t, r.IsValid("alice", alice, 2))
+	assert.True(t, r.IsValid("bob", bob, 1))
+	assert.Equal(t, ErrNeverIssued, r.Validate("carol", 0, 1))
+}
+
+func Test_Registry_RevokeLockAndUnlockOnlyAffectKey(t *testing.T) {
+	r := NewCounterRegistry(4)
+	alice := r.Issue("alice")
+	bob := r.Issue("bob")
+
+	r.Lock("alice")
+	assert.True(t, r.IsLocked("alice"))
+	assert.Equal(t, ErrLocked, r.Validate("alice", alice, 1))
+	assert.True(t, r.IsValid("bob", bob, 1))
+
+	r.Unlock("alice")
+	assert.True(t, r.IsValid("alice", alice, 1))
+
+	r.Revoke("alice", 1)
+	assert.Equal(t, ErrRevoked, r.Validate("alice", alice, 1))
+	assert.True(t, r.IsValid("bob", bob, 1))
+}
+
+func Test_Registry_MutationsOfMissingKeysHaveNoEffect(t *testing.T) {
+	r := NewCounterRegistry(4)
+
+	r.Lock("alice")
+	r.Unlock("alice")
+	r.Revoke("alice", 1)
+
+	assert.Equal(t, 0, r.Len())
+}
+
+func Test_Registry_HoldsTimeouts(t *testing.T) {
+	now := time.Now()
+	clock.NowForce(now)
+	defer clock.NowReset()
+
+	r := NewTimeoutRegistry(4)
+	s := r.Issue("alice")
+
+	assert.Equal(t, SessionCAA(now.Unix()), s)
+	assert.True(t, r.IsValid("alice", s, 10))
+
+	r.Revoke("alice", now.Unix()+1)
+	assert.Equal(t, ErrRevoked, r.Validate("alice", s, 10))
+}
+
+func Test_Registry_SnapshotAndRestoreRoundTrip(t *testing.T) {
+	r := NewCounterRegistry(8)
+	for i := 0; i < 100; i++ {
+		for j := 0; j <= i%5; j++ {
+			r.Issue(fmt.Sprintf("user-%d", i))
+		}
+	}
+	r.Lock("user-7")
+
+	snapshot := r.Snapshot()
+	assert.Len(t, snapshot, 100)
+	assert.Equal(t, int64(-3), snapshot["user-7"])
+
+	restored := NewCounterRegistry(3)
+	restored.Restore(snapshot)
+	assert.Equal(t, snapshot, restored.Snapshot())
+}
+
+func Test_Registry_RangeVisitsCopiesOfEveryKey(t *testing.T) {
+	r := NewCounterRegistry(8)
+	for i := 0; i < 20; i++ {
+		r.Issue(fmt.Sprintf("user-%d", i))
+	}
+
+	visited := map[string]bool{}
+	r.Range(func(key string, caa CAA) bool {
+		visited[key] = true
+		caa.Lock()
+		return true
+	})
+
+	assert.Len(t, visited, 20)
+	assert.False(t, r.IsLocked("user-0"))
+
+	n := 0
+	r.Range(func(key string, caa CAA) bool {
+		n++
+		return n < 5
+	})
+	assert.Equal(t, 5, n)
+}
+
+func Test_Registry_ConcurrentIssuesAreNotLost(t *testing.T) {
+	r := NewCounterRegistry(4)
+	keys := []string{"alice", "bob", "carol"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		for _, key := range keys {
+			wg.Add(1)
+			go func(key string) {
+				defer wg.Done()
+				r.Issue(key)
+				r.IsValid(key, 0, 100)
+			}(key)
+		}
+	}
+	wg.Wait()
+
+	for _, key := range keys {
+		assert.Equal(t, int64(100), r.Snapshot()[key])
+	}
+}
@@ -0,0 +1,192 @@
+package compandauth
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnknownScope is returned when using a scope Scoped has no policy
+	// for.
+	ErrUnknownScope = errors.New("compandauth: unknown scope")
+	// ErrInvalidPolicy is returned by NewScoped for policies with an unknown
+	// type or parent, or whose parents form a cycle.
+	ErrInvalidPolicy = errors.New("compandauth: invalid scope policy")
+)
+
+// ScopePolicy configures a scope of Scoped. Delta is passed when validating
+// the scope's sessions: the number of sessions for a Counter, or the duration
+// for a Timeout. Parent optionally names another scope that this scope
+// escalates from, sessions of this scope are invalid whilst any of its
+// ancestors are locked (e.g. locking "login" locks out "sudo").
+type ScopePolicy struct {
+	Type   Kind
+	Delta  int64
+	Parent string
+}
+
+// Scoped holds a CAA per named scope (e.g. "login", "sudo", "payments") of an
+// entity. Only the CAA values are state to be persisted, the policies are
+// configuration. Scoped is not safe for concurrent use.
+type Scoped struct {
+	policies map[string]ScopePolicy
+	caas     map[string]int64
+}
+
+// Returns a Scoped with the given policies, each scope having never issued.
+// The policies are copied, so changing them afterwards has no effect.
+func NewScoped(policies map[string]ScopePolicy) (*Scoped, error) {
+	copied := make(map[string]ScopePolicy, len(policies))
+	for scope, policy := range policies {
+		copied[scope] = policy
+	}
+	policies = copied
+
+	for scope, policy := range policies {
+		if newKindCAA(policy.Type, new(int64)) == nil {
+			return nil, fmt.Errorf("%w: scope %q has unknown type %q", ErrInvalidPolicy, scope, policy.Type)
+		}
+
+		seen := map[string]bool{scope: true}
+		for parent := policy.Parent; parent != ""; parent = policies[parent].Parent {
+			if _, ok := policies[parent]; !ok {
+				return nil, fmt.Errorf("%w: scope %q has unknown parent %q", ErrInvalidPolicy, scope, parent)
+			}
+			if seen[parent] {
+				return nil, fmt.Errorf("%w: scope %q has cyclic parents", ErrInvalidPolicy, scope)
+			}
+			seen[parent] = true
+		}
+	}
+
+	return &Scoped{policies: policies, caas: map[string]int64{}}, nil
+}
+
+// Issues the next session CAA for scope.
+func (s *Scoped) Issue(scope string) (SessionCAA, error) {
+	var sessionCAA SessionCAA
+	err := s.update(scope, func(caa CAA) { sessionCAA = caa.Issue() })
+
+	return sessionCAA, err
+}
+
+// Validates a session CAA of scope using the scope's policy, returning
+// ErrLocked if the scope or any of its ancestors are locked. See
+// Counter.Validate and Timeout.Validate for other errors.
+func (s *Scoped) Validate(scope string, sessionCAA SessionCAA) error {
+	policy, ok := s.policies[scope]
+	if !ok {
+		return ErrUnknownScope
+	}
+
+	for parent := policy.Parent; parent != ""; parent = s.policies[parent].Parent {
+		if s.caa(parent).IsLocked() {
+			return ErrLocked
+		}
+	}
+
+	return s.caa(scope).Validate(sessionCAA, policy.Delta)
+}
+
+func (s *Scoped) IsValid(scope string, sessionCAA SessionCAA) bool {
+	return s.Validate(scope, sessionCAA) == nil
+}
+
+// Revokes sessions of scope, see Counter.Revoke and Timeout.Revoke.
+func (s *Scoped) Revoke(scope string, n int64) error {
+	return s.update(scope, func(caa CAA) { caa.Revoke(n) })
+}
+
+// Locks scope, and so implicitly any scopes escalating from it.
+func (s *Scoped) Lock(scope string) error {
+	return s.update(scope, CAA.Lock)
+}
+
+func (s *Scoped) Unlock(scope string) error {
+	return s.update(scope, CAA.Unlock)
+}
+
+// Indicates if scope itself is locked, regardless of its ancestors.
+func (s *Scoped) IsLocked(scope string) bool {
+	if _, ok := s.policies[scope]; !ok {
+		return false
+	}
+
+	return s.caa(scope).IsLocked()
+}
+
+// Returns the raw CAA value of each scope that has issued.
+func (s *Scoped) Values() map[string]int64 {
+	values := make(map[string]int64, len(s.caas))
+	for scope, v := range s.caas {
+		values[scope] = v
+	}
+
+	return values
+}
+
+// MarshalJSON implements json.Marshaler as an object of scope to raw CAA
+// value.
+func (s *Scoped) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.caas)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Values of scopes without a
+// policy are kept so they aren't lost when persisting again.
+func (s *Scoped) UnmarshalJSON(b []byte) error {
+	var caas map[string]int64
+	if err := json.Unmarshal(b, &caas); err != nil {
+		return err
+	}
+
+	if caas == nil {
+		caas = map[string]int64{}
+	}
+	s.caas = caas
+	return nil
+}
+
+// Value implements driver.Valuer, storing the set of CAAs as JSON.
+func (s *Scoped) Value() (driver.Value, error) {
+	return s.MarshalJSON()
+}
+
+// Scan implements sql.Scanner, a NULL is considered a set of CAAs that have
+// never issued.
+func (s *Scoped) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		s.caas = map[string]int64{}
+		return nil
+	case []byte:
+		return s.UnmarshalJSON(v)
+	case string:
+		return s.UnmarshalJSON([]byte(v))
+	}
+
+	return fmt.Errorf("compandauth: scanning Scoped: unsupported type %T", src)
+}
+
+// Returns a copy of scope's CAA, which must have a policy.
+func (s *Scoped) caa(scope string) CAA {
+	v := s.caas[scope]
+
+	return newKindCAA(s.policies[scope].Type, &v)
+}
+
+func (s *Scoped) update(scope string, fn func(CAA)) error {
+	policy, ok := s.policies[scope]
+	if !ok {
+		return ErrUnknownScope
+	}
+
+	v := s.caas[scope]
+	fn(newKindCAA(policy.Type, &v))
+	if v != 0 {
+		s.caas[scope] = v
+	}
+
+	return nil
+}
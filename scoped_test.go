@@ -0,0 +1,157 @@
+package compandauth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/endiangroup/compandauth/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestScoped(t *testing.T) *Scoped {
+	scoped, err := NewScoped(map[string]ScopePolicy{
+		"login":    {Type: KindCounter, Delta: 2},
+		"sudo":     {Type: KindTimeout, Delta: 300, Parent: "login"},
+		"payments": {Type: KindTimeout, Delta: 60, Parent: "sudo"},
+		"api-key":  {Type: KindCounter, Delta: 1},
+	})
+	assert.NoError(t, err)
+
+	return scoped
+}
+
+func Test_Scoped_IssuesAndValidatesPerScopePolicy(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	clock.NowForce(now)
+	defer clock.NowReset()
+	scoped := newTestScoped(t)
+
+	login, err := scoped.Issue("login")
+	assert.NoError(t, err)
+	sudo, err := scoped.Issue("sudo")
+	assert.NoError(t, err)
+
+	assert.Equal(t, SessionCAA(0), login)
+	assert.Equal(t, SessionCAA(now.Unix()), sudo)
+	assert.NoError(t, scoped.Validate("login", login))
+	assert.NoError(t, scoped.Validate("sudo", sudo))
+
+	clock.NowForce(now.Add(301 * time.Second))
+	assert.Equal(t, ErrExpired, scoped.Validate("sudo", sudo))
+	assert.True(t, scoped.IsValid("login", login))
+	assert.Equal(t, ErrNeverIssued, scoped.Validate("api-key", 0))
+}
+
+func Test_Scoped_LockingAScopeLocksScopesEscalatingFromIt(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	clock.NowForce(now)
+	defer clock.NowReset()
+	scoped := newTestScoped(t)
+
+	scoped.Issue("login")
+	sudo, _ := scoped.Issue("sudo")
+	payments, _ := scoped.Issue("payments")
+	apiKey, _ := scoped.Issue("api-key")
+
+	assert.NoError(t, scoped.Lock("login"))
+
+	assert.True(t, scoped.IsLocked("login"))
+	assert.False(t, scoped.IsLocked("sudo"))
+	assert.Equal(t, ErrLocked, scoped.Validate("sudo", sudo))
+	assert.Equal(t, ErrLocked, scoped.Validate("payments", payments))
+	assert.NoError(t, scoped.Validate("api-key", apiKey))
+
+	assert.NoError(t, scoped.Unlock("login"))
+	assert.NoError(t, scoped.Validate("payments", payments))
+}
+
+func Test_Scoped_RevokeOnlyAffectsScope(t *testing.T) {
+	scoped := newTestScoped(t)
+	login, _ := scoped.Issue("login")
+	apiKey, _ := scoped.Issue("api-key")
+
+	assert.NoError(t, scoped.Revoke("login", 2))
+
+	assert.Equal(t, ErrRevoked, scoped.Validate("login", login))
+	assert.NoError(t, scoped.Validate("api-key", apiKey))
+}
+
+func Test_Scoped_ReturnsErrUnknownScope(t *testing.T) {
+	scoped := newTestScoped(t)
+
+	_, err := scoped.Issue("admin")
+	assert.Equal(t, ErrUnknownScope, err)
+	assert.Equal(t, ErrUnknownScope, scoped.Validate("admin", 0))
+	assert.Equal(t, ErrUnknownScope, scoped.Lock("admin"))
+	assert.Equal(t, ErrUnknownScope, scoped.Unlock("admin"))
+	assert.Equal(t, ErrUnknownScope, scoped.Revoke("admin", 1))
+	assert.False(t, scoped.IsLocked("admin"))
+}
+
+func Test_NewScoped_RejectsInvalidPolicies(t *testing.T) {
+	tests := map[string]map[string]ScopePolicy{
+		"unknown type":   {"login": {Type: "bitmap"}},
+		"unknown parent": {"sudo": {Type: KindTimeout, Parent: "login"}},
+		"cyclic parents": {
+			"a": {Type: KindCounter, Parent: "b"},
+			"b": {Type: KindCounter, Parent: "a"},
+		},
+		"own parent": {"a": {Type: KindCounter, Parent: "a"}},
+	}
+
+	for name, policies := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewScoped(policies)
+
+			assert.True(t, errors.Is(err, ErrInvalidPolicy))
+		})
+	}
+}
+
+func Test_NewScoped_CopiesPolicies(t *testing.T) {
+	policies := map[string]ScopePolicy{
+		"login": {Type: KindCounter, Delta: 1},
+		"sudo":  {Type: KindCounter, Delta: 1, Parent: "login"},
+	}
+	scoped, err := NewScoped(policies)
+	assert.NoError(t, err)
+
+	policies["login"] = ScopePolicy{Type: KindCounter, Delta: 1, Parent: "sudo"}
+	policies["admin"] = ScopePolicy{Type: KindCounter}
+
+	s, err := scoped.Issue("sudo")
+	assert.NoError(t, err)
+	assert.NoError(t, scoped.Validate("sudo", s))
+	assert.Equal(t, ErrUnknownScope, scoped.Validate("admin", 0))
+}
+
+func Test_Scoped_JSONAndSQLRoundTrip(t *testing.T) {
+	scoped := newTestScoped(t)
+	scoped.Issue("login")
+	scoped.Issue("login")
+	scoped.Issue("api-key")
+	scoped.Lock("api-key")
+
+	b, err := scoped.MarshalJSON()
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"login":2,"api-key":-1}`, string(b))
+
+	fromJSON := newTestScoped(t)
+	assert.NoError(t, fromJSON.UnmarshalJSON(b))
+	assert.Equal(t, scoped.Values(), fromJSON.Values())
+
+	v, err := scoped.Value()
+	assert.NoError(t, err)
+	fromSQL := newTestScoped(t)
+	assert.NoError(t, fromSQL.Scan(v))
+	assert.Equal(t, scoped.Values(), fromSQL.Values())
+	assert.True(t, fromSQL.IsLocked("api-key"))
+
+	assert.NoError(t, fromSQL.Scan(nil))
+	assert.Empty(t, fromSQL.Values())
+	assert.NoError(t, fromSQL.Scan(`null`))
+	_, err = fromSQL.Issue("login")
+	assert.NoError(t, err)
+	assert.Error(t, fromSQL.Scan(42))
+}
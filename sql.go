@@ -0,0 +1,121 @@
+package compandauth
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Scan implements sql.Scanner. A NULL is considered a Counter that has never
+// issued.
+func (caa *Counter) Scan(src interface{}) error {
+	i, err := scanInt64(src)
+	if err != nil {
+		return fmt.Errorf("compandauth: scanning Counter: %v", err)
+	}
+
+	*caa = Counter(i)
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (caa Counter) Value() (driver.Value, error) {
+	return int64(caa), nil
+}
+
+// Scan implements sql.Scanner. A NULL is considered a Timeout that has never
+// issued.
+func (caa *Timeout) Scan(src interface{}) error {
+	i, err := scanInt64(src)
+	if err != nil {
+		return fmt.Errorf("compandauth: scanning Timeout: %v", err)
+	}
+
+	*caa = Timeout(i)
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (caa Timeout) Value() (driver.Value, error) {
+	return int64(caa), nil
+}
+
+// Scan implements sql.Scanner. A NULL is considered a TimeoutMillis that has
+// never issued.
+func (caa *TimeoutMillis) Scan(src interface{}) error {
+	i, err := scanInt64(src)
+	if err != nil {
+		return fmt.Errorf("compandauth: scanning TimeoutMillis: %v", err)
+	}
+
+	*caa = TimeoutMillis(i)
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (caa TimeoutMillis) Value() (driver.Value, error) {
+	return int64(caa), nil
+}
+
+// Scan implements sql.Scanner. A NULL is rejected rather than considered a
+// SessionCAA of 0, as 0 is the first session a Counter issues, scan nullable
+// columns into a sql.NullInt64 instead.
+func (s *SessionCAA) Scan(src interface{}) error {
+	if src == nil {
+		return fmt.Errorf("compandauth: scanning SessionCAA: NULL, use sql.NullInt64 for nullable columns")
+	}
+
+	i, err := scanInt64(src)
+	if err != nil {
+		return fmt.Errorf("compandauth: scanning SessionCAA: %v", err)
+	}
+
+	*s = SessionCAA(i)
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (s SessionCAA) Value() (driver.Value, error) {
+	return int64(s), nil
+}
+
+// Converts the types a driver may hand to a sql.Scanner into an int64,
+// rejecting anything that can't be represented losslessly. math.MinInt64 is
+// rejected as it has no positive counterpart and so can't be locked/unlocked.
+func scanInt64(src interface{}) (int64, error) {
+	var i int64
+
+	switch v := src.(type) {
+	case nil:
+		return 0, nil
+	case int64:
+		i = v
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, fmt.Errorf("value %d out of range", v)
+		}
+		i = int64(v)
+	case float64:
+		if v != math.Trunc(v) || v < -math.MaxInt64 || v >= math.MaxInt64 {
+			return 0, fmt.Errorf("value %v is not an integer in range", v)
+		}
+		i = int64(v)
+	case []byte:
+		return scanInt64(string(v))
+	case string:
+		p, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		i = p
+	default:
+		return 0, fmt.Errorf("unsupported type %T", src)
+	}
+
+	if i == math.MinInt64 {
+		return 0, fmt.Errorf("value %d out of range", i)
+	}
+
+	return i, nil
+}
@@ -0,0 +1,205 @@
+package compandauth
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A single row table per DSN, Exec replaces the row and Query returns it.
+type fakeDriver struct {
+	mu   sync.Mutex
+	rows map[string][]driver.Value
+}
+
+var fakeDB = &fakeDriver{rows: map[string][]driver.Value{}}
+
+func init() {
+	sql.Register("compandauth-fake", fakeDB)
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	return &fakeConn{driver: d, dsn: dsn}, nil
+}
+
+func (d *fakeDriver) set(dsn string, row []driver.Value) {
+	d.mu.Lock()
+	d.rows[dsn] = row
+	d.mu.Unlock()
+}
+
+func (d *fakeDriver) get(dsn string) []driver.Value {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.rows[dsn]
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+	dsn    string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return &fakeStmt{conn: c}, nil }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	conn *fakeConn
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.conn.driver.set(s.conn.dsn, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &fakeRows{row: s.conn.driver.get(s.conn.dsn)}, nil
+}
+
+type fakeRows struct {
+	row  []driver.Value
+	done bool
+}
+
+func (r *fakeRows) Columns() []string {
+	columns := make([]string, len(r.row))
+	for i := range columns {
+		columns[i] = fmt.Sprintf("c%d", i)
+	}
+	return columns
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.row)
+	return nil
+}
+
+func Test_SQL_RoundTripsCounterTimeoutAndSessionCAA(t *testing.T) {
+	db, err := sql.Open("compandauth-fake", t.Name())
+	assert.NoError(t, err)
+	defer db.Close()
+
+	counter, timeout, session := Counter(-12), Timeout(1500000000), SessionCAA(11)
+	_, err = db.Exec("UPDATE users SET caa = ?, sudo_caa = ?, last_session = ?", counter, timeout, session)
+	assert.NoError(t, err)
+
+	var scannedCounter Counter
+	var scannedTimeout Timeout
+	var scannedSession SessionCAA
+	err = db.QueryRow("SELECT caa, sudo_caa, last_session FROM users").Scan(&scannedCounter, &scannedTimeout, &scannedSession)
+	assert.NoError(t, err)
+
+	assert.Equal(t, counter, scannedCounter)
+	assert.Equal(t, timeout, scannedTimeout)
+	assert.Equal(t, session, scannedSession)
+}
+
+func Test_SQL_ScansNullAsNeverIssued(t *testing.T) {
+	db, err := sql.Open("compandauth-fake", t.Name())
+	assert.NoError(t, err)
+	defer db.Close()
+
+	fakeDB.set(t.Name(), []driver.Value{nil, nil})
+
+	counter, timeout := Counter(5), Timeout(5)
+	err = db.QueryRow("SELECT caa, sudo_caa FROM users").Scan(&counter, &timeout)
+	assert.NoError(t, err)
+
+	assert.False(t, counter.HasIssued())
+	assert.False(t, timeout.HasIssued())
+}
+
+func Test_SQL_RejectsNullSessionCAA(t *testing.T) {
+	db, err := sql.Open("compandauth-fake", t.Name())
+	assert.NoError(t, err)
+	defer db.Close()
+
+	fakeDB.set(t.Name(), []driver.Value{nil})
+
+	session := SessionCAA(5)
+	err = db.QueryRow("SELECT last_session FROM users").Scan(&session)
+	assert.Error(t, err)
+	assert.Equal(t, SessionCAA(5), session)
+
+	var nullable sql.NullInt64
+	assert.NoError(t, db.QueryRow("SELECT last_session FROM users").Scan(&nullable))
+	assert.False(t, nullable.Valid)
+}
+
+func Test_Scan_AcceptsIntegerRepresentations(t *testing.T) {
+	tests := []struct {
+		Src      interface{}
+		Expected int64
+	}{
+		{Src: int64(-5), Expected: -5},
+		{Src: int64(math.MaxInt64), Expected: math.MaxInt64},
+		{Src: uint64(7), Expected: 7},
+		{Src: float64(42), Expected: 42},
+		{Src: []byte("-12"), Expected: -12},
+		{Src: "1500000000", Expected: 1500000000},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%+v", test), func(t *testing.T) {
+			var counter Counter
+			var timeout Timeout
+			var session SessionCAA
+
+			assert.NoError(t, counter.Scan(test.Src))
+			assert.NoError(t, timeout.Scan(test.Src))
+			assert.NoError(t, session.Scan(test.Src))
+
+			assert.Equal(t, Counter(test.Expected), counter)
+			assert.Equal(t, Timeout(test.Expected), timeout)
+			assert.Equal(t, SessionCAA(test.Expected), session)
+		})
+	}
+}
+
+func Test_Scan_RejectsOutOfRangeAndNonIntegerValues(t *testing.T) {
+	tests := []struct {
+		Src interface{}
+	}{
+		{Src: int64(math.MinInt64)},
+		{Src: uint64(math.MaxInt64 + 1)},
+		{Src: float64(1.5)},
+		{Src: float64(math.MaxInt64)},
+		{Src: math.Inf(1)},
+		{Src: math.NaN()},
+		{Src: "9223372036854775808"},
+		{Src: "-9223372036854775808"},
+		{Src: []byte("12.5")},
+		{Src: "abc"},
+		{Src: true},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%+v", test), func(t *testing.T) {
+			counter, timeout, session := Counter(3), Timeout(3), SessionCAA(3)
+
+			assert.Error(t, counter.Scan(test.Src))
+			assert.Error(t, timeout.Scan(test.Src))
+			assert.Error(t, session.Scan(test.Src))
+
+			assert.Equal(t, Counter(3), counter)
+			assert.Equal(t, Timeout(3), timeout)
+			assert.Equal(t, SessionCAA(3), session)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"context"
+	"sync"
+)
+
+// Memory is an in-process Store, useful for tests and single instance
+// deployments.
+type Memory struct {
+	mu   sync.Mutex
+	caas map[string]int64
+}
+
+func NewMemory() *Memory {
+	return &Memory{
+		caas: map[string]int64{},
+	}
+}
+
+// Set unconditionally stores raw under key.
+func (m *Memory) Set(key string, raw int64) {
+	m.mu.Lock()
+	m.caas[key] = raw
+	m.mu.Unlock()
+}
+
+func (m *Memory) Load(ctx context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	raw, ok := m.caas[key]
+	if !ok {
+		return 0, ErrNotFound
+	}
+
+	return raw, nil
+}
+
+func (m *Memory) CompareAndSwap(ctx context.Context, key string, old, new int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current, ok := m.caas[key]
+	if !ok {
+		return false, ErrNotFound
+	}
+
+	if current != old {
+		return false, nil
+	}
+
+	m.caas[key] = new
+	return true, nil
+}
+
+var _ = Store(NewMemory())
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Placeholder returns the bind variable for the nth (1 indexed) query
+// argument.
+type Placeholder func(n int) string
+
+// Question produces ? placeholders, as used by MySQL and SQLite.
+func Question(n int) string {
+	return "?"
+}
+
+// Dollar produces $n placeholders, as used by Postgres.
+func Dollar(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+// SQL is a Store backed by a database/sql table with a key column and an
+// integer CAA column. Swaps are performed with a single conditional UPDATE so
+// no row lock is held between loading and storing a CAA. A NULL CAA column is
+// considered to have never issued.
+type SQL struct {
+	db   *sql.DB
+	load string
+	swap string
+}
+
+// NewSQL returns a Store for caaColumn of table, keyed by keyColumn. The
+// table and column names are interpolated into the queries as is so must not
+// come from untrusted input.
+func NewSQL(db *sql.DB, table, keyColumn, caaColumn string, placeholder Placeholder) *SQL {
+	return &SQL{
+		db: db,
+		load: fmt.Sprintf("SELECT COALESCE(%s, 0) FROM %s WHERE %s = %s",
+			caaColumn, table, keyColumn, placeholder(1)),
+		swap: fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s = %s AND COALESCE(%s, 0) = %s",
+			table, caaColumn, placeholder(1), keyColumn, placeholder(2), caaColumn, placeholder(3)),
+	}
+}
+
+func (s *SQL) Load(ctx context.Context, key string) (int64, error) {
+	var raw int64
+
+	err := s.db.QueryRowContext(ctx, s.load, key).Scan(&raw)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+
+	return raw, err
+}
+
+func (s *SQL) CompareAndSwap(ctx context.Context, key string, old, new int64) (bool, error) {
+	result, err := s.db.ExecContext(ctx, s.swap, new, key, old)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+var _ = Store(&SQL{})
@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/endiangroup/compandauth"
+	"github.com/stretchr/testify/assert"
+)
+
+// Stands in for a single "users" table with "id" and "caa" columns, only
+// understanding the two queries the SQL store issues.
+type fakeDriver struct {
+	mu   sync.Mutex
+	rows map[string]driver.Value
+}
+
+var fakeDB = &fakeDriver{rows: map[string]driver.Value{}}
+
+func init() {
+	sql.Register("store-fake", fakeDB)
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) { return &fakeConn{d}, nil }
+
+func (d *fakeDriver) set(key string, v driver.Value) {
+	d.mu.Lock()
+	d.rows[key] = v
+	d.mu.Unlock()
+}
+
+type fakeConn struct {
+	driver *fakeDriver
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{driver: c.driver, query: query}, nil
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type fakeStmt struct {
+	driver *fakeDriver
+	query  string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	d := s.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !strings.HasPrefix(s.query, "UPDATE") {
+		return nil, errors.New("unexpected exec: " + s.query)
+	}
+
+	new, key, old := args[0], args[1].(string), args[2]
+	current, ok := d.rows[key]
+	if current == nil {
+		current = int64(0)
+	}
+
+	if !ok || current != old {
+		return driver.RowsAffected(0), nil
+	}
+
+	d.rows[key] = new
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	d := s.driver
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !strings.HasPrefix(s.query, "SELECT") {
+		return nil, errors.New("unexpected query: " + s.query)
+	}
+
+	current, ok := d.rows[args[0].(string)]
+	if !ok {
+		return &fakeRows{}, nil
+	}
+	if current == nil {
+		current = int64(0)
+	}
+
+	return &fakeRows{row: []driver.Value{current}}, nil
+}
+
+type fakeRows struct {
+	row []driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return []string{"caa"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.row == nil {
+		return io.EOF
+	}
+	copy(dest, r.row)
+	r.row = nil
+	return nil
+}
+
+func newFakeSQL(t *testing.T) *SQL {
+	db, err := sql.Open("store-fake", "")
+	assert.NoError(t, err)
+
+	return NewSQL(db, "users", "id", "caa", Dollar)
+}
+
+func Test_NewSQL_BuildsQueriesForTableAndColumns(t *testing.T) {
+	s := newFakeSQL(t)
+
+	assert.Equal(t, "SELECT COALESCE(caa, 0) FROM users WHERE id = $1", s.load)
+	assert.Equal(t, "UPDATE users SET caa = $1 WHERE id = $2 AND COALESCE(caa, 0) = $3", s.swap)
+}
+
+func Test_SQL_LoadReturnsErrNotFoundForMissingRow(t *testing.T) {
+	_, err := newFakeSQL(t).Load(context.Background(), "missing")
+
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func Test_SQL_CompareAndSwapOnlySwapsWhenOldMatches(t *testing.T) {
+	s := newFakeSQL(t)
+	fakeDB.set("cas", int64(3))
+
+	swapped, err := s.CompareAndSwap(context.Background(), "cas", 2, 4)
+	assert.NoError(t, err)
+	assert.False(t, swapped)
+
+	swapped, err = s.CompareAndSwap(context.Background(), "cas", 3, 4)
+	assert.NoError(t, err)
+	assert.True(t, swapped)
+
+	raw, err := s.Load(context.Background(), "cas")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), raw)
+}
+
+func Test_SQL_MutateIssuesOnNullCAA(t *testing.T) {
+	s := newFakeSQL(t)
+	fakeDB.set("null", nil)
+
+	var sessionCAA compandauth.SessionCAA
+	caa := compandauth.NewCounter()
+	err := Mutate(context.Background(), s, "null", caa, func(caa compandauth.CAA) {
+		sessionCAA = caa.Issue()
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, compandauth.SessionCAA(0), sessionCAA)
+	assert.True(t, caa.IsValid(sessionCAA, 1))
+
+	raw, err := s.Load(context.Background(), "null")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), raw)
+}
@@ -0,0 +1,100 @@
+// Package store provides optimistic, compare-and-swap based persistence of
+// CAA values so that Issue, Revoke, Lock and Unlock can be applied without
+// holding a row lock for the duration of an authentication flow.
+package store
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/endiangroup/compandauth"
+)
+
+// DefaultMaxAttempts is the number of times Mutate will reload and retry on
+// conflict before giving up with ErrConflict.
+const DefaultMaxAttempts = 10
+
+var (
+	// ErrNotFound is returned when no CAA is stored under a key.
+	ErrNotFound = errors.New("store: caa not found")
+	// ErrConflict is returned by Mutate when it failed to swap in a new
+	// value after DefaultMaxAttempts attempts.
+	ErrConflict = errors.New("store: too many conflicting updates")
+)
+
+// Store persists the raw int64 value of a CAA against a key.
+type Store interface {
+	// Load returns the raw CAA value stored under key, or ErrNotFound.
+	Load(ctx context.Context, key string) (int64, error)
+	// CompareAndSwap sets the CAA value stored under key to new only if it
+	// is currently old, reporting if the swap took place.
+	CompareAndSwap(ctx context.Context, key string, old, new int64) (bool, error)
+}
+
+// Persistable is a CAA that can be converted to and from its stored raw
+// value, both *compandauth.Counter and *compandauth.Timeout satisfy it.
+type Persistable interface {
+	compandauth.CAA
+	sql.Scanner
+	driver.Valuer
+}
+
+// Mutate loads the CAA stored under key into caa, applies fn to it and
+// attempts to swap the result back in. If the stored value changed in the
+// mean time it reloads and retries, up to DefaultMaxAttempts times. On
+// success caa holds the value that was stored.
+func Mutate(ctx context.Context, s Store, key string, caa Persistable, fn func(compandauth.CAA)) error {
+	for attempt := 0; attempt < DefaultMaxAttempts; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		old, err := s.Load(ctx, key)
+		if err != nil {
+			return err
+		}
+
+		if err := caa.Scan(old); err != nil {
+			return err
+		}
+
+		fn(caa)
+
+		new, err := rawValue(caa)
+		if err != nil {
+			return err
+		}
+
+		if new == old {
+			return nil
+		}
+
+		swapped, err := s.CompareAndSwap(ctx, key, old, new)
+		if err != nil {
+			return err
+		}
+
+		if swapped {
+			return nil
+		}
+	}
+
+	return ErrConflict
+}
+
+func rawValue(caa driver.Valuer) (int64, error) {
+	v, err := caa.Value()
+	if err != nil {
+		return 0, err
+	}
+
+	i, ok := v.(int64)
+	if !ok {
+		return 0, fmt.Errorf("store: unsupported caa value type %T", v)
+	}
+
+	return i, nil
+}
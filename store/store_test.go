@@ -0,0 +1,135 @@
+package store
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/endiangroup/compandauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Mutate_AppliesFnAndStoresResult(t *testing.T) {
+	s := NewMemory()
+	s.Set("user", 5)
+
+	caa := compandauth.NewCounter()
+	err := Mutate(context.Background(), s, "user", caa, func(caa compandauth.CAA) {
+		caa.Revoke(2)
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, compandauth.Counter(7), *caa)
+
+	raw, err := s.Load(context.Background(), "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), raw)
+}
+
+func Test_Mutate_ReturnsErrNotFoundForUnknownKey(t *testing.T) {
+	err := Mutate(context.Background(), NewMemory(), "user", compandauth.NewCounter(), func(caa compandauth.CAA) {
+		caa.Issue()
+	})
+
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func Test_Mutate_RejectsCAAsNotStoredAsASingleInt64(t *testing.T) {
+	s := NewMemory()
+	s.Set("user", 1500000000)
+
+	err := Mutate(context.Background(), s, "user", compandauth.NewSliding(0), func(caa compandauth.CAA) {
+		caa.Issue()
+	})
+	assert.Error(t, err)
+
+	raw, err := s.Load(context.Background(), "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1500000000), raw)
+}
+
+func Test_Mutate_ReloadsAndRetriesOnConflict(t *testing.T) {
+	s := &conflictingStore{Memory: NewMemory(), conflicts: 3}
+	s.Set("user", 1)
+
+	calls := 0
+	caa := compandauth.NewCounter()
+	err := Mutate(context.Background(), s, "user", caa, func(caa compandauth.CAA) {
+		calls++
+		caa.Issue()
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, calls)
+	// Each conflict issued a session behind our back
+	assert.Equal(t, compandauth.Counter(1+3+1), *caa)
+}
+
+func Test_Mutate_GivesUpAfterMaxAttempts(t *testing.T) {
+	s := &conflictingStore{Memory: NewMemory(), conflicts: DefaultMaxAttempts}
+	s.Set("user", 1)
+
+	err := Mutate(context.Background(), s, "user", compandauth.NewCounter(), func(caa compandauth.CAA) {
+		caa.Issue()
+	})
+
+	assert.Equal(t, ErrConflict, err)
+}
+
+func Test_Mutate_StopsWhenContextIsDone(t *testing.T) {
+	s := NewMemory()
+	s.Set("user", 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := Mutate(ctx, s, "user", compandauth.NewCounter(), func(caa compandauth.CAA) {
+		caa.Issue()
+	})
+
+	assert.Equal(t, context.Canceled, err)
+}
+
+func Test_Mutate_NoConcurrentIssuesAreLost(t *testing.T) {
+	s := NewMemory()
+	s.Set("user", 1)
+	goroutines := 20
+
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				err := Mutate(context.Background(), s, "user", compandauth.NewCounter(), func(caa compandauth.CAA) {
+					caa.Issue()
+				})
+				if err != ErrConflict {
+					assert.NoError(t, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	raw, err := s.Load(context.Background(), "user")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1+goroutines), raw)
+}
+
+// Simulates another writer getting in between a Load and CompareAndSwap by
+// issuing on the stored value for the first n swaps.
+type conflictingStore struct {
+	*Memory
+	conflicts int
+}
+
+func (s *conflictingStore) CompareAndSwap(ctx context.Context, key string, old, new int64) (bool, error) {
+	if s.conflicts > 0 {
+		s.conflicts--
+		s.Set(key, old+1)
+	}
+
+	return s.Memory.CompareAndSwap(ctx, key, old, new)
+}
@@ -0,0 +1,178 @@
+// Package token encodes session CAAs into compact, URL safe, HMAC-SHA256
+// signed strings suitable for cookies or headers, for services that don't
+// already have a signed session mechanism such as JWT.
+//
+// A token has the form
+//
+//	v1.<key id>.<base64url payload>.<base64url signature>
+//
+// where the signature covers everything before the final '.', and the payload
+// is JSON.
+package token
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/endiangroup/compandauth"
+	"github.com/endiangroup/compandauth/clock"
+)
+
+const version = "v1"
+
+// MinKeyLen is the minimum length of a signing key in bytes.
+const MinKeyLen = 32
+
+var (
+	// ErrMalformed is returned when decoding a string that isn't a token.
+	ErrMalformed = errors.New("token: malformed token")
+	// ErrUnknownKey is returned when decoding a token signed with a key ID
+	// the Signer doesn't have.
+	ErrUnknownKey = errors.New("token: unknown key id")
+	// ErrInvalidSignature is returned when a token's signature doesn't match
+	// its contents.
+	ErrInvalidSignature = errors.New("token: invalid signature")
+	// ErrInvalidKey is returned when adding a key that is too short or whose
+	// ID is empty or contains a '.'.
+	ErrInvalidKey = errors.New("token: invalid key")
+)
+
+// Token is the signed content of an encoded token. IssuedAt has second
+// precision.
+type Token struct {
+	Entity   string
+	CAA      compandauth.SessionCAA
+	IssuedAt time.Time
+	Claims   map[string]string
+}
+
+type payload struct {
+	Entity   string                 `json:"sub"`
+	CAA      compandauth.SessionCAA `json:"caa"`
+	IssuedAt int64                  `json:"iat"`
+	Claims   map[string]string      `json:"cl,omitempty"`
+}
+
+var encoding = base64.RawURLEncoding
+
+// Signer encodes tokens with its current key and decodes tokens signed with
+// any of its keys, allowing keys to be rotated by adding the new key and
+// retiring the old one once tokens signed with it have expired. A Signer is
+// safe for concurrent use once all keys have been added.
+type Signer struct {
+	kid  string
+	keys map[string][]byte
+}
+
+// Returns a Signer that signs with key, identified by kid.
+func NewSigner(kid string, key []byte) (*Signer, error) {
+	s := &Signer{keys: map[string][]byte{}}
+	if err := s.AddKey(kid, key); err != nil {
+		return nil, err
+	}
+	s.kid = kid
+
+	return s, nil
+}
+
+// Adds a key that tokens can be decoded with, use Rotate to also sign new
+// tokens with it.
+func (s *Signer) AddKey(kid string, key []byte) error {
+	if kid == "" || strings.Contains(kid, ".") || len(key) < MinKeyLen {
+		return ErrInvalidKey
+	}
+
+	s.keys[kid] = append([]byte(nil), key...)
+	return nil
+}
+
+// Signs new tokens with the previously added key kid.
+func (s *Signer) Rotate(kid string) error {
+	if _, ok := s.keys[kid]; !ok {
+		return ErrUnknownKey
+	}
+
+	s.kid = kid
+	return nil
+}
+
+// Removes a key, tokens signed with it will no longer decode. The current
+// signing key can't be removed.
+func (s *Signer) RemoveKey(kid string) {
+	if kid != s.kid {
+		delete(s.keys, kid)
+	}
+}
+
+// Encodes and signs t. If t.IssuedAt is zero it is set to clock.Now.
+func (s *Signer) Encode(t Token) (string, error) {
+	if t.IssuedAt.IsZero() {
+		t.IssuedAt = clock.Now()
+	}
+
+	b, err := json.Marshal(payload{
+		Entity:   t.Entity,
+		CAA:      t.CAA,
+		IssuedAt: t.IssuedAt.Unix(),
+		Claims:   t.Claims,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signed := version + "." + s.kid + "." + encoding.EncodeToString(b)
+
+	return signed + "." + encoding.EncodeToString(sign(s.keys[s.kid], signed)), nil
+}
+
+// Verifies and decodes a token produced by Encode.
+func (s *Signer) Decode(token string) (Token, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 4 || parts[0] != version {
+		return Token{}, ErrMalformed
+	}
+
+	key, ok := s.keys[parts[1]]
+	if !ok {
+		return Token{}, ErrUnknownKey
+	}
+
+	mac, err := encoding.DecodeString(parts[3])
+	if err != nil {
+		return Token{}, ErrMalformed
+	}
+
+	signed := token[:len(token)-len(parts[3])-1]
+	if !hmac.Equal(mac, sign(key, signed)) {
+		return Token{}, ErrInvalidSignature
+	}
+
+	b, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return Token{}, ErrMalformed
+	}
+
+	var p payload
+	if err := json.Unmarshal(b, &p); err != nil {
+		return Token{}, ErrMalformed
+	}
+
+	return Token{
+		Entity:   p.Entity,
+		CAA:      p.CAA,
+		IssuedAt: time.Unix(p.IssuedAt, 0).UTC(),
+		Claims:   p.Claims,
+	}, nil
+}
+
+func sign(key []byte, signed string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(signed))
+
+	return h.Sum(nil)
+}
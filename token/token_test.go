@@ -0,0 +1,152 @@
+package token
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/endiangroup/compandauth"
+	"github.com/endiangroup/compandauth/clock"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	key1 = []byte("0123456789abcdef0123456789abcdef")
+	key2 = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func newSigner(t *testing.T) *Signer {
+	s, err := NewSigner("k1", key1)
+	assert.NoError(t, err)
+
+	return s
+}
+
+func Test_Encode_MatchesTestVectors(t *testing.T) {
+	tests := []struct {
+		Token    Token
+		Expected string
+	}{
+		{
+			Token:    Token{Entity: "user-1", CAA: 42, IssuedAt: time.Unix(1500000000, 0)},
+			Expected: "v1.k1.eyJzdWIiOiJ1c2VyLTEiLCJjYWEiOjQyLCJpYXQiOjE1MDAwMDAwMDB9.l6PDUC1v58dO8Or5pihtrjEbqJ8Wjn920cOoSZ97EYE",
+		},
+		{
+			Token:    Token{Entity: "user-1", CAA: 42, IssuedAt: time.Unix(1500000000, 0), Claims: map[string]string{"scope": "sudo"}},
+			Expected: "v1.k1.eyJzdWIiOiJ1c2VyLTEiLCJjYWEiOjQyLCJpYXQiOjE1MDAwMDAwMDAsImNsIjp7InNjb3BlIjoic3VkbyJ9fQ.6rFE-hDH9ZkF0rIjSBDjwFiPN2z9zOFbcqPjMkZcWME",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%+v", test.Token), func(t *testing.T) {
+			encoded, err := newSigner(t).Encode(test.Token)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, encoded)
+		})
+	}
+}
+
+func Test_Decode_RoundTripsEncode(t *testing.T) {
+	now := time.Unix(1500000000, 0).UTC()
+	clock.NowForce(now)
+	defer clock.NowReset()
+
+	tests := []Token{
+		{Entity: "user-1", CAA: 0},
+		{Entity: "", CAA: -5},
+		{Entity: "a.b.c", CAA: 12, Claims: map[string]string{"scope": "sudo", "device": "iPhone"}},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%+v", test), func(t *testing.T) {
+			s := newSigner(t)
+			encoded, err := s.Encode(test)
+			assert.NoError(t, err)
+
+			decoded, err := s.Decode(encoded)
+			assert.NoError(t, err)
+
+			test.IssuedAt = now
+			assert.Equal(t, test, decoded)
+		})
+	}
+}
+
+func Test_Decode_RejectsTamperedTokens(t *testing.T) {
+	s := newSigner(t)
+	encoded, err := s.Encode(Token{Entity: "user-1", CAA: 42})
+	assert.NoError(t, err)
+	parts := strings.Split(encoded, ".")
+
+	forged, err := s.Encode(Token{Entity: "user-1", CAA: 43})
+	assert.NoError(t, err)
+	forgedPayload := strings.Split(forged, ".")[2]
+
+	tests := map[string]struct {
+		Token       string
+		ExpectedErr error
+	}{
+		"swapped payload":   {Token: strings.Join([]string{parts[0], parts[1], forgedPayload, parts[3]}, "."), ExpectedErr: ErrInvalidSignature},
+		"truncated mac":     {Token: encoded[:len(encoded)-3], ExpectedErr: ErrInvalidSignature},
+		"unknown key":       {Token: strings.Join([]string{parts[0], "k2", parts[2], parts[3]}, "."), ExpectedErr: ErrUnknownKey},
+		"unknown version":   {Token: "v2" + encoded[2:], ExpectedErr: ErrMalformed},
+		"missing part":      {Token: strings.Join(parts[:3], "."), ExpectedErr: ErrMalformed},
+		"extra part":        {Token: encoded + ".", ExpectedErr: ErrMalformed},
+		"non base64 mac":    {Token: strings.Join([]string{parts[0], parts[1], parts[2], "!!"}, "."), ExpectedErr: ErrMalformed},
+		"empty":             {Token: "", ExpectedErr: ErrMalformed},
+		"signed non base64": {Token: signedWith(key1, "v1.k1.!!"), ExpectedErr: ErrMalformed},
+		"signed non json":   {Token: signedWith(key1, "v1.k1."+encoding.EncodeToString([]byte("caa"))), ExpectedErr: ErrMalformed},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := s.Decode(test.Token)
+
+			assert.Equal(t, test.ExpectedErr, err)
+		})
+	}
+}
+
+func signedWith(key []byte, signed string) string {
+	return signed + "." + encoding.EncodeToString(sign(key, signed))
+}
+
+func Test_Signer_RotatesKeys(t *testing.T) {
+	s := newSigner(t)
+	old, err := s.Encode(Token{Entity: "user-1", CAA: 1})
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.AddKey("k2", key2))
+	assert.NoError(t, s.Rotate("k2"))
+	new, err := s.Encode(Token{Entity: "user-1", CAA: 2})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(new, "v1.k2."))
+
+	decoded, err := s.Decode(old)
+	assert.NoError(t, err)
+	assert.Equal(t, compandauth.SessionCAA(1), decoded.CAA)
+
+	s.RemoveKey("k1")
+	_, err = s.Decode(old)
+	assert.Equal(t, ErrUnknownKey, err)
+
+	s.RemoveKey("k2")
+	decoded, err = s.Decode(new)
+	assert.NoError(t, err)
+	assert.Equal(t, compandauth.SessionCAA(2), decoded.CAA)
+
+	assert.Equal(t, ErrUnknownKey, s.Rotate("k3"))
+}
+
+func Test_Signer_RejectsInvalidKeys(t *testing.T) {
+	_, err := NewSigner("k1", key1[:MinKeyLen-1])
+	assert.Equal(t, ErrInvalidKey, err)
+
+	_, err = NewSigner("", key1)
+	assert.Equal(t, ErrInvalidKey, err)
+
+	_, err = NewSigner("k.1", key1)
+	assert.Equal(t, ErrInvalidKey, err)
+}